@@ -0,0 +1,95 @@
+package jira
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// adfNode is a node of the Atlassian Document Format, the rich text format of the
+// REST API v3. Only what is needed to read and write plain text is mapped.
+type adfNode struct {
+	Type    string    `json:"type"`
+	Version int       `json:"version,omitempty"`
+	Text    string    `json:"text,omitempty"`
+	Attrs   *adfAttrs `json:"attrs,omitempty"`
+	Content []adfNode `json:"content,omitempty"`
+}
+
+// adfAttrs holds the attributes of inline nodes that carry their text there instead of
+// in a text node, like mentions, emojis and status lozenges.
+type adfAttrs struct {
+	Text string `json:"text,omitempty"`
+}
+
+// adfBlockParents are the node types whose children are blocks, each of which starts
+// on a new line.
+var adfBlockParents = map[string]bool{
+	"doc":          true,
+	"bulletList":   true,
+	"orderedList":  true,
+	"listItem":     true,
+	"blockquote":   true,
+	"panel":        true,
+	"expand":       true,
+	"nestedExpand": true,
+	"table":        true,
+	"tableRow":     true,
+	"tableHeader":  true,
+	"tableCell":    true,
+}
+
+// adfDocument wraps text in a document with one paragraph per line.
+func adfDocument(text string) *adfNode {
+	doc := &adfNode{Type: "doc", Version: 1, Content: []adfNode{}}
+	for _, line := range strings.Split(text, "\n") {
+		paragraph := adfNode{Type: "paragraph"}
+		if line != "" {
+			paragraph.Content = []adfNode{{Type: "text", Text: line}}
+		}
+		doc.Content = append(doc.Content, paragraph)
+	}
+	return doc
+}
+
+// plainText returns the text of the node, with every block on a separate line however
+// deep it is nested.
+func (n *adfNode) plainText() string {
+	switch n.Type {
+	case "text":
+		return n.Text
+	case "hardBreak":
+		return "\n"
+	case "mention", "emoji", "date", "status":
+		if n.Attrs == nil {
+			return ""
+		}
+		return n.Attrs.Text
+	}
+	var b strings.Builder
+	for i := range n.Content {
+		child := &n.Content[i]
+		if i > 0 && adfBlockParents[n.Type] {
+			b.WriteString("\n")
+		}
+		b.WriteString(child.plainText())
+	}
+	return b.String()
+}
+
+// commentText returns the text of a comment that is either a plain string or an
+// Atlassian Document Format document.
+func commentText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	if raw[0] == '"' {
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+	var doc adfNode
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return "", err
+	}
+	return doc.plainText(), nil
+}
@@ -0,0 +1,34 @@
+package jira
+
+import (
+	"testing"
+)
+
+func TestCommentText(t *testing.T) {
+	raw := `{"type":"doc","version":1,"content":[` +
+		`{"type":"bulletList","content":[` +
+		`{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"first"}]}]},` +
+		`{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"second"}]},` +
+		`{"type":"orderedList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"nested"}]}]}]}]}]},` +
+		`{"type":"blockquote","content":[{"type":"paragraph","content":[{"type":"text","text":"quoted"}]},{"type":"paragraph","content":[{"type":"text","text":"twice"}]}]},` +
+		`{"type":"paragraph","content":[{"type":"text","text":"ping "},{"type":"mention","attrs":{"id":"5b10a2844c20165700ede21g","text":"@Jane"}},` +
+		`{"type":"text","text":" "},{"type":"emoji","attrs":{"shortName":":smile:","text":"😄"}},` +
+		`{"type":"text","text":" "},{"type":"status","attrs":{"text":"DONE","color":"green"}}]}]}`
+
+	text, err := commentText([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "first\nsecond\nnested\nquoted\ntwice\nping @Jane 😄 DONE"
+	if text != expected {
+		t.Fatal("Wanted", expected, "but got", text)
+	}
+
+	text, err = commentText([]byte(`"plain"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "plain" {
+		t.Fatal("Wanted", "plain", "but got", text)
+	}
+}
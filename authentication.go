@@ -2,20 +2,42 @@ package jira
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
-	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// AuthImpl is the OAuth 2.0 (3LO) AuthService. It is safe for concurrent use.
 type AuthImpl struct {
-	client       *client
+	client *client
+
+	mu           sync.Mutex
 	accessToken  string
 	refreshToken string
+	// expiresAt is when the access token expires, zero if unknown
+	expiresAt time.Time
+	// refreshSkew is how long before expiresAt the token is refreshed
+	refreshSkew time.Duration
+	scope       string
+	// refreshing is the refresh in flight, if any, other callers wait for it
+	refreshing *refreshCall
+
+	// store persists the tokens, loaded is set once they were read from it
+	store  TokenStore
+	loaded bool
+}
+
+// refreshCall is a token refresh that concurrent callers share.
+type refreshCall struct {
+	done chan struct{}
+	resp *OAuthResponse
+	err  error
 }
 
 type AuthService interface {
@@ -23,8 +45,21 @@ type AuthService interface {
 	SetRefreshToken(refreshToken string)
 	GetAccessToken() string
 	SetAccessToken(accessToken string)
+	InvalidateAccessToken(accessToken string)
+	GetAccessTokenExpiry() time.Time
+	SetAccessTokenExpiry(expiresAt time.Time)
+	GetScopes() []string
+	GetValidAccessToken() (string, error)
+	GetValidAccessTokenContext(ctx context.Context) (string, error)
 	GetAccessTokenFromAuthorizationCode(code string) (*OAuthResponse, error)
+	GetAccessTokenFromAuthorizationCodeContext(ctx context.Context, code string) (*OAuthResponse, error)
 	GetAccessTokenFromRefreshToken() (*OAuthResponse, error)
+	GetAccessTokenFromRefreshTokenContext(ctx context.Context) (*OAuthResponse, error)
+	NewAuthorizationRequest(scopes ...string) (*AuthorizationRequest, error)
+	GetAccessTokenFromCallback(authRequest *AuthorizationRequest, callback url.Values) (*OAuthResponse, error)
+	GetAccessTokenFromCallbackContext(ctx context.Context, authRequest *AuthorizationRequest, callback url.Values) (*OAuthResponse, error)
+	Logout() error
+	LogoutContext(ctx context.Context) error
 }
 
 type OAuthRequest struct {
@@ -33,6 +68,7 @@ type OAuthRequest struct {
 	ClientSecret string `json:"client_secret"`
 	Code         string `json:"code"`
 	RedirectURI  string `json:"redirect_uri"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
 }
 
 type OAuthRefreshRequest struct {
@@ -51,136 +87,383 @@ type OAuthResponse struct {
 }
 
 func (c *AuthImpl) GetRefreshToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustLoadLocked()
 	return c.refreshToken
 }
 
 func (c *AuthImpl) GetAccessToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustLoadLocked()
 	return c.accessToken
 }
 
+// SetAccessToken sets the access token. Its expiry is unknown until SetAccessTokenExpiry
+// is called, so it is only refreshed once Jira rejects it.
 func (c *AuthImpl) SetAccessToken(accessToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustLoadLocked()
 	c.accessToken = accessToken
+	c.expiresAt = time.Time{}
+	c.saveLocked()
+}
+
+// GetAccessTokenExpiry returns when the access token expires, the zero time if unknown.
+func (c *AuthImpl) GetAccessTokenExpiry() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustLoadLocked()
+	return c.expiresAt
+}
+
+// SetAccessTokenExpiry sets when the access token expires. The token is refreshed
+// shortly before, see WithTokenRefreshSkew.
+func (c *AuthImpl) SetAccessTokenExpiry(expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustLoadLocked()
+	c.expiresAt = expiresAt
+	c.saveLocked()
+}
+
+// GetScopes returns the scopes granted to the access token, as reported by the auth
+// server or loaded from the TokenStore. It is empty if they are unknown.
+func (c *AuthImpl) GetScopes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustLoadLocked()
+	return strings.Fields(c.scope)
 }
 
 func (c *AuthImpl) SetRefreshToken(refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustLoadLocked()
 	c.refreshToken = refreshToken
+	c.saveLocked()
 }
 
-func (a *AuthImpl) GetAccessTokenFromAuthorizationCode(code string) (*OAuthResponse, error) {
-	u := url.URL{
-		Scheme: a.client.getScheme(),
-		Host:   a.client.getAuthUrl(),
-		Path:   "oauth/token",
+// InvalidateAccessToken forgets the access token, but only if it still is accessToken.
+// A request that failed with a stale token thus cannot throw away the token another
+// goroutine has just refreshed.
+func (c *AuthImpl) InvalidateAccessToken(accessToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustLoadLocked()
+	if c.accessToken == accessToken {
+		c.accessToken = ""
+		c.saveLocked()
 	}
+}
 
-	method := "POST"
+func (a *AuthImpl) GetValidAccessToken() (string, error) {
+	return a.GetValidAccessTokenContext(context.Background())
+}
+
+// GetValidAccessTokenContext returns the access token, refreshing it first if there is
+// none or it is about to expire. Concurrent callers share a single refresh.
+func (a *AuthImpl) GetValidAccessTokenContext(ctx context.Context) (string, error) {
+	resp, err := a.refreshAccessToken(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	return resp.AccessToken, nil
+}
+
+func (a *AuthImpl) GetAccessTokenFromAuthorizationCode(code string) (*OAuthResponse, error) {
+	return a.GetAccessTokenFromAuthorizationCodeContext(context.Background(), code)
+}
+
+// GetAccessTokenFromAuthorizationCodeContext is like GetAccessTokenFromAuthorizationCode
+// but aborts the token exchange when ctx is done.
+// The tokens are kept for the following requests and saved to the TokenStore, if any.
+func (a *AuthImpl) GetAccessTokenFromAuthorizationCodeContext(ctx context.Context, code string) (*OAuthResponse, error) {
+	return a.exchangeCode(ctx, code, "")
+}
 
+// exchangeCode trades an authorization code for tokens, sending the PKCE code verifier if set.
+func (a *AuthImpl) exchangeCode(ctx context.Context, code string, codeVerifier string) (*OAuthResponse, error) {
 	payload := OAuthRequest{
 		GrantType:    "authorization_code",
 		ClientID:     a.client.getClientID(),
 		ClientSecret: a.client.getClientSecret(),
 		Code:         code,
 		RedirectURI:  a.client.getRedirectURL(),
+		CodeVerifier: codeVerifier,
 	}
 
-	requestBody, err := json.Marshal(&payload)
+	resp, err := a.postToken(ctx, "GetAccessTokenFromAuthorizationCode", &payload)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// loading afterwards would overwrite the new tokens
+	a.loaded = true
+	a.setTokenLocked(resp)
+	return resp, nil
+}
 
-	req, err := http.NewRequest(method, u.String(), bytes.NewBuffer(requestBody))
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-	req.Header.Add("Content-Type", "application/json")
+func (a *AuthImpl) GetAccessTokenFromRefreshToken() (*OAuthResponse, error) {
+	return a.GetAccessTokenFromRefreshTokenContext(context.Background())
+}
 
-	res, err := client.Do(req)
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-	defer res.Body.Close()
+// GetAccessTokenFromRefreshTokenContext is like GetAccessTokenFromRefreshToken
+// but aborts the token refresh when ctx is done.
+// The new access token and a rotated refresh token are kept for the following requests
+// and saved to the TokenStore, if any. If a refresh is already in flight, the call
+// waits for it instead of sending another one.
+func (a *AuthImpl) GetAccessTokenFromRefreshTokenContext(ctx context.Context) (*OAuthResponse, error) {
+	return a.refreshAccessToken(ctx, true)
+}
 
-	if res.StatusCode != 200 {
-		bytesResp, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			log.Println("[GetAccessTokenFromAuthorizationCode] Error reading response body" + err.Error())
+// refreshAccessToken coalesces concurrent refreshes into one call to the auth server.
+// Unless force is set, the current access token is returned if it is still valid.
+func (a *AuthImpl) refreshAccessToken(ctx context.Context, force bool) (*OAuthResponse, error) {
+	for {
+		a.mu.Lock()
+		if err := a.loadLocked(ctx); err != nil {
+			a.mu.Unlock()
 			return nil, err
 		}
-		log.Println("[GetAccessTokenFromAuthorizationCode] Error body", string(bytesResp))
-		log.Println("[GetAccessTokenFromAuthorizationCode] Error calling jira auth api. Wanted 200 but got code " + strconv.FormatInt(int64(res.StatusCode), 10))
+		if !force && a.validLocked() {
+			resp := &OAuthResponse{AccessToken: a.accessToken, RefreshToken: a.refreshToken}
+			a.mu.Unlock()
+			return resp, nil
+		}
+		call := a.refreshing
+		if call == nil {
+			// another client sharing the store may have rotated the tokens in the meantime,
+			// refreshing with the old refresh token would fail
+			a.reloadLocked(ctx)
+			if !force && a.validLocked() {
+				resp := &OAuthResponse{AccessToken: a.accessToken, RefreshToken: a.refreshToken}
+				a.mu.Unlock()
+				return resp, nil
+			}
+			call = &refreshCall{done: make(chan struct{})}
+			a.refreshing = call
+			payload := OAuthRefreshRequest{
+				GrantType:    "refresh_token",
+				ClientID:     a.client.getClientID(),
+				ClientSecret: a.client.getClientSecret(),
+				RefreshToken: a.refreshToken,
+			}
+			a.mu.Unlock()
+
+			call.resp, call.err = a.postToken(ctx, "GetAccessTokenFromRefreshToken", &payload)
+
+			a.mu.Lock()
+			// a logout while the refresh was in flight drops the call, keep the tokens wiped
+			if call.err == nil && a.refreshing == call {
+				a.setTokenLocked(call.resp)
+			}
+			if a.refreshing == call {
+				a.refreshing = nil
+			}
+			a.mu.Unlock()
+			close(call.done)
+			return call.resp, call.err
+		}
+		a.mu.Unlock()
 
-		return nil, errors.New("[GetAccessTokenFromAuthorizationCode] Error calling jira auth api. Wanted 200 but got code " + strconv.FormatInt(int64(res.StatusCode), 10))
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// the caller that sent the refresh gave up, try again with our own context
+		if ctx.Err() == nil && (errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded)) {
+			continue
+		}
+		return call.resp, call.err
 	}
+}
 
+// postToken sends payload to the oauth/token endpoint of the auth server.
+// caller prefixes the log lines.
+func (a *AuthImpl) postToken(ctx context.Context, caller string, payload interface{}) (*OAuthResponse, error) {
 	var resp OAuthResponse
-	err = json.NewDecoder(res.Body).Decode(&resp)
-	if err != nil {
-		fmt.Println(err)
+	if err := a.postAuth(ctx, caller, "oauth/token", payload, &resp); err != nil {
 		return nil, err
 	}
-
 	return &resp, nil
 }
 
-func (a *AuthImpl) GetAccessTokenFromRefreshToken() (*OAuthResponse, error) {
+// postAuth sends payload as JSON to endpoint of the auth server and decodes the answer
+// into v, unless v is nil. caller prefixes the log lines.
+func (a *AuthImpl) postAuth(ctx context.Context, caller string, endpoint string, payload interface{}, v interface{}) error {
 	u := url.URL{
 		Scheme: a.client.getScheme(),
 		Host:   a.client.getAuthUrl(),
-		Path:   "oauth/token",
+		Path:   endpoint,
 	}
 
 	method := "POST"
 
-	payload := OAuthRefreshRequest{
-		GrantType:    "refresh_token",
-		ClientID:     a.client.getClientID(),
-		ClientSecret: a.client.getClientSecret(),
-		RefreshToken: a.refreshToken,
-	}
-
-	requestBody, err := json.Marshal(&payload)
+	requestBody, err := json.Marshal(payload)
 	if err != nil {
-		log.Println(err)
-		return nil, err
+		return err
 	}
 
-	client := &http.Client{}
-
-	req, err := http.NewRequest(method, u.String(), bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewBuffer(requestBody))
 	if err != nil {
-		log.Println(err)
-		return nil, err
+		return err
 	}
 	req.Header.Add("Content-Type", "application/json")
+	if a.client.userAgent != "" {
+		req.Header.Set("User-Agent", a.client.userAgent)
+	}
 
-	res, err := client.Do(req)
+	res, err := a.client.httpClient.Do(req)
 	if err != nil {
-		log.Println(err)
-		return nil, err
+		a.client.logger.Error("calling auth server failed", "caller", caller, "error", err)
+		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
 		bytesResp, err := ioutil.ReadAll(res.Body)
 		if err != nil {
-			log.Println("[GetAccessTokenFromRefreshToken] Error reading response body" + err.Error())
-			return nil, err
+			a.client.logger.Error("reading auth error response failed", "caller", caller, "status", res.StatusCode, "error", err)
+			return err
 		}
-		log.Println("[GetAccessTokenFromRefreshToken] Error body", string(bytesResp))
-		log.Println("[GetAccessTokenFromRefreshToken] Error calling jira auth api. Wanted 200 but got code " + strconv.FormatInt(int64(res.StatusCode), 10))
-		return nil, errors.New("[GetAccessTokenFromRefreshToken] Error calling jira auth api. Wanted 200 but got code " + strconv.FormatInt(int64(res.StatusCode), 10))
+		a.client.logger.Warn("auth server returned an error", "caller", caller, "status", res.StatusCode, "body", string(bytesResp))
+		return newError(res, bytesResp)
 	}
 
-	var resp OAuthResponse
-	err = json.NewDecoder(res.Body).Decode(&resp)
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// validLocked reports whether there is an access token that does not expire within
+// the refresh skew. a.mu must be held.
+func (a *AuthImpl) validLocked() bool {
+	if a.accessToken == "" {
+		return false
+	}
+	return a.expiresAt.IsZero() || a.client.now().Add(a.refreshSkew).Before(a.expiresAt)
+}
+
+// expiry converts the relative expires_in of resp into a point in time.
+func (a *AuthImpl) expiry(resp *OAuthResponse) time.Time {
+	if resp.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return a.client.now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+}
+
+// setTokenLocked keeps the tokens of resp and saves them. The auth server only sends
+// a refresh token when it rotates it. a.mu must be held.
+func (a *AuthImpl) setTokenLocked(resp *OAuthResponse) {
+	a.accessToken = resp.AccessToken
+	if resp.RefreshToken != "" {
+		a.refreshToken = resp.RefreshToken
+	}
+	a.expiresAt = a.expiry(resp)
+	if resp.Scope != "" {
+		a.scope = resp.Scope
+	}
+	a.saveLocked()
+}
+
+// loadLocked reads the tokens from the store the first time they are needed.
+// a.mu must be held.
+func (a *AuthImpl) loadLocked(ctx context.Context) error {
+	if a.store == nil || a.loaded {
+		return nil
+	}
+	token, err := a.store.Load(ctx)
+	if errors.Is(err, ErrNoToken) {
+		a.loaded = true
+		return nil
+	}
 	if err != nil {
-		log.Println(err)
-		return nil, err
+		return err
 	}
+	a.accessToken = token.AccessToken
+	a.refreshToken = token.RefreshToken
+	a.expiresAt = token.Expiry
+	a.scope = token.Scope
+	a.loaded = true
+	return nil
+}
 
-	return &resp, nil
+// reloadLocked reads the tokens from the store again and takes them over if another
+// client saved different ones since. A failing store keeps the tokens in memory.
+// a.mu must be held.
+func (a *AuthImpl) reloadLocked(ctx context.Context) {
+	if a.store == nil {
+		return
+	}
+	token, err := a.store.Load(ctx)
+	if err != nil {
+		if !errors.Is(err, ErrNoToken) {
+			a.client.logger.Error("reloading token failed", "error", err)
+		}
+		return
+	}
+	if token.RefreshToken == a.refreshToken && token.AccessToken == a.accessToken {
+		return
+	}
+	a.accessToken = token.AccessToken
+	a.refreshToken = token.RefreshToken
+	a.expiresAt = token.Expiry
+	a.scope = token.Scope
+}
+
+// mustLoadLocked is loadLocked for the methods that cannot return an error.
+// a.mu must be held.
+func (a *AuthImpl) mustLoadLocked() {
+	if err := a.loadLocked(context.Background()); err != nil {
+		a.client.logger.Error("loading token failed", "error", err)
+	}
+}
+
+// saveLocked writes the tokens to the store. A token that cannot be saved is still
+// valid, so the failure is logged rather than failing the request. a.mu must be held.
+func (a *AuthImpl) saveLocked() {
+	if a.store == nil {
+		return
+	}
+	// not the request context, a rotated refresh token must not get lost on cancellation
+	err := a.store.Save(context.Background(), &Token{
+		AccessToken:  a.accessToken,
+		RefreshToken: a.refreshToken,
+		Expiry:       a.expiresAt,
+		Scope:        a.scope,
+		TokenType:    "Bearer",
+	})
+	if err != nil {
+		a.client.logger.Error("saving token failed", "error", err)
+	}
+}
+
+// Authenticate sets the bearer access token on req, refreshing it first if needed.
+// If the auth server refuses the refresh, the error is a *RefreshError.
+func (a *AuthImpl) Authenticate(req *http.Request) error {
+	token, err := a.GetValidAccessTokenContext(req.Context())
+	var authErr *Error
+	if errors.As(err, &authErr) && authErr.StatusCode < 500 {
+		// the session is gone, not the auth server
+		return &RefreshError{Err: err}
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Invalidate forgets the access token req was sent with after Jira rejected it,
+// so that the next Authenticate refreshes it.
+func (a *AuthImpl) Invalidate(req *http.Request) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	a.InvalidateAccessToken(token)
 }
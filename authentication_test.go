@@ -3,7 +3,12 @@ package jira
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestClient_GetAccessTokenFromRefreshToken(t *testing.T) {
@@ -105,3 +110,137 @@ func TestClient_GetAccessTokenFromAuthorizationCode(t *testing.T) {
 		t.Fatal("Wanted", expected.TokenType, "but got", actual.TokenType)
 	}
 }
+
+func TestAuthImpl_ConcurrentRefresh(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var refreshes int32
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&refreshes, 1)
+		// keep the refresh in flight long enough for the other goroutines to pile up
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "fresh-" + strconv.Itoa(int(n))})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	testMux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh-1" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&searchResult{Issues: []Issue{{Key: "TEST-1"}}})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	testClient.GetAuthService().SetAccessToken("stale")
+	testClient.GetAuthService().SetRefreshToken("refresh")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := testClient.GetIssueService().Search("test", nil)
+			if err != nil {
+				errs <- err
+			}
+			_ = testClient.GetAuthService().GetRefreshToken()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&refreshes) != 1 {
+		t.Fatal("Wanted 1 refresh but got", refreshes)
+	}
+
+	if testClient.GetAuthService().GetAccessToken() != "fresh-1" {
+		t.Fatal("Wanted access token fresh-1 but got", testClient.GetAuthService().GetAccessToken())
+	}
+}
+
+func TestAuthImpl_InvalidateAccessToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	auth := testClient.GetAuthService()
+	auth.SetAccessToken("new")
+	auth.InvalidateAccessToken("old")
+	if auth.GetAccessToken() != "new" {
+		t.Fatal("Wanted a stale invalidation to keep the new token but got", auth.GetAccessToken())
+	}
+
+	auth.InvalidateAccessToken("new")
+	if auth.GetAccessToken() != "" {
+		t.Fatal("Wanted the token to be invalidated but got", auth.GetAccessToken())
+	}
+}
+
+func TestAuthImpl_ProactiveRefresh(t *testing.T) {
+	setup()
+	defer teardown()
+
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := newClient(addr,
+		WithScheme("http"),
+		WithAuthDomain(addr),
+		WithOAuthApp("test", "test", "test"),
+		WithTokenRefreshSkew(30*time.Second),
+		WithClock(func() time.Time { return now }),
+	)
+
+	refreshes := 0
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		refreshes++
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "token-" + strconv.Itoa(refreshes), ExpiresIn: 3600})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	token, err := c.GetAuthService().GetValidAccessToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-1" {
+		t.Fatal("Wanted token-1 but got", token)
+	}
+
+	expiry := c.GetAuthService().GetAccessTokenExpiry()
+	if !expiry.Equal(now.Add(time.Hour)) {
+		t.Fatal("Wanted expiry", now.Add(time.Hour), "but got", expiry)
+	}
+
+	now = now.Add(59 * time.Minute)
+	token, err = c.GetAuthService().GetValidAccessToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-1" || refreshes != 1 {
+		t.Fatal("Wanted token-1 without a refresh but got", token, "after", refreshes, "refreshes")
+	}
+
+	// 20 seconds left, within the skew
+	now = now.Add(40 * time.Second)
+	token, err = c.GetAuthService().GetValidAccessToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-2" || refreshes != 2 {
+		t.Fatal("Wanted a refreshed token-2 but got", token, "after", refreshes, "refreshes")
+	}
+}
@@ -0,0 +1,50 @@
+package jira
+
+import "net/http"
+
+// Authenticator signs the requests the client sends to Jira, e.g. by setting the
+// Authorization header. The AuthImpl of the client is the default Authenticator.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// RefreshableAuthenticator is an Authenticator whose credentials can be renewed.
+// When Jira answers with 401, the client calls Invalidate with the rejected request
+// and retries it once, so that Authenticate can fetch new credentials.
+type RefreshableAuthenticator interface {
+	Authenticator
+	Invalidate(req *http.Request)
+}
+
+// PersonalAccessToken authenticates with a Personal Access Token of Jira Server or
+// Data Center, sent as bearer token. There is no refresh endpoint, a 401 is final.
+type PersonalAccessToken struct {
+	token string
+}
+
+// NewPersonalAccessToken returns a PersonalAccessToken for token.
+func NewPersonalAccessToken(token string) *PersonalAccessToken {
+	return &PersonalAccessToken{token: token}
+}
+
+func (p *PersonalAccessToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	return nil
+}
+
+// BasicAuth authenticates with HTTP Basic auth, using the email address and an API
+// token of an Atlassian account. There is nothing to refresh, a 401 is final.
+type BasicAuth struct {
+	email    string
+	apiToken string
+}
+
+// NewBasicAuth returns a BasicAuth for the account with email and apiToken.
+func NewBasicAuth(email string, apiToken string) *BasicAuth {
+	return &BasicAuth{email: email, apiToken: apiToken}
+}
+
+func (b *BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(b.email, b.apiToken)
+	return nil
+}
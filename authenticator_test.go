@@ -0,0 +1,92 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	setup()
+	defer teardown()
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := New(addr, WithScheme("http"), WithBasicAuth("bot@example.com", "api-token"))
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("Did not want an OAuth refresh with basic auth")
+	})
+
+	calls := 0
+	testMux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		email, token, ok := r.BasicAuth()
+		if !ok || email != "bot@example.com" || token != "api-token" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&searchResult{Issues: []Issue{{Key: "TEST-1"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	issues, err := c.GetIssueService().Search("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 {
+		t.Fatal("Wanted 1 issue but got", len(issues))
+	}
+
+	calls = 0
+	c = New(addr, WithScheme("http"), WithBasicAuth("bot@example.com", "revoked"))
+	_, err = c.GetIssueService().Search("test", nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatal("Wanted ErrUnauthorized but got", err)
+	}
+	if calls != 1 {
+		t.Fatal("Wanted a 401 with basic auth not to be retried but got", calls, "calls")
+	}
+}
+
+func TestDataCenter(t *testing.T) {
+	setup()
+	defer teardown()
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := New(addr, WithScheme("http"), WithBasePath("jira"), WithDataCenter("pat"))
+
+	testMux.HandleFunc("/jira/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pat" {
+			t.Error("Wanted the personal access token but got", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(200)
+		_, err := w.Write([]byte(`{"issues":[{"key":"TEST-1","fields":{"assignee":{"name":"jdoe","key":"JIRAUSER10000"}}}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	issues, err := c.GetIssueService().Search("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.GetDeployment() != DeploymentServer {
+		t.Fatal("Wanted DeploymentServer but got", c.GetDeployment())
+	}
+
+	assignee := issues[0].Fields.Assignee
+	if assignee.Identifier(c.GetDeployment()) != "jdoe" {
+		t.Fatal("Wanted the username jdoe but got", assignee.Identifier(c.GetDeployment()))
+	}
+
+	cloudUser := User{AccountID: "5b10a2844c20165700ede21g", Name: "ignored"}
+	if cloudUser.Identifier(DeploymentCloud) != cloudUser.AccountID {
+		t.Fatal("Wanted the account ID on Cloud but got", cloudUser.Identifier(DeploymentCloud))
+	}
+}
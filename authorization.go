@@ -0,0 +1,137 @@
+package jira
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// audience is the API the OAuth 2.0 (3LO) tokens are issued for.
+const audience = "api.atlassian.com"
+
+var (
+	// ErrStateMismatch is returned when the state of the authorization callback is not the one
+	// the authorization request was sent with, e.g. because the callback was forged.
+	ErrStateMismatch = errors.New("jira: authorization state mismatch")
+
+	// ErrMissingCode is returned when the authorization callback carries neither a code nor an error.
+	ErrMissingCode = errors.New("jira: authorization callback without code")
+)
+
+// AuthorizationRequest is one run of the OAuth 2.0 (3LO) authorization code flow.
+// Send the user to URL and keep the request, e.g. in the session, until the auth server
+// redirects back to the redirect URI. Then pass the callback to GetAccessTokenFromCallback.
+type AuthorizationRequest struct {
+	// URL is the auth.atlassian.com/authorize URL the user has to visit
+	URL string
+	// State is the random value that ties the callback to this request
+	State string
+	// CodeVerifier is the PKCE secret whose challenge is part of URL
+	CodeVerifier string
+	// RedirectURI is where the auth server sends the user back to
+	RedirectURI string
+}
+
+// AuthorizationError is the error the auth server reports in the callback,
+// e.g. "access_denied" when the user declined the consent.
+type AuthorizationError struct {
+	Code        string
+	Description string
+}
+
+func (e *AuthorizationError) Error() string {
+	if e.Description == "" {
+		return "jira: authorization failed: " + e.Code
+	}
+	return "jira: authorization failed: " + e.Code + ": " + e.Description
+}
+
+// NewAuthorizationRequest starts the authorization code flow for scopes, e.g.
+// "read:jira-work" and "offline_access" to get a refresh token as well.
+// It generates a fresh state and PKCE code verifier and builds the authorize URL,
+// asking the user for consent every time.
+func (a *AuthImpl) NewAuthorizationRequest(scopes ...string) (*AuthorizationRequest, error) {
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+
+	u := url.URL{
+		Scheme: a.client.getScheme(),
+		Host:   a.client.getAuthUrl(),
+		Path:   "authorize",
+	}
+	uv := url.Values{}
+	uv.Add("audience", audience)
+	uv.Add("client_id", a.client.getClientID())
+	uv.Add("scope", strings.Join(scopes, " "))
+	uv.Add("redirect_uri", a.client.getRedirectURL())
+	uv.Add("state", state)
+	uv.Add("response_type", "code")
+	uv.Add("prompt", "consent")
+	uv.Add("code_challenge", codeChallenge(verifier))
+	uv.Add("code_challenge_method", "S256")
+	u.RawQuery = uv.Encode()
+
+	return &AuthorizationRequest{
+		URL:          u.String(),
+		State:        state,
+		CodeVerifier: verifier,
+		RedirectURI:  a.client.getRedirectURL(),
+	}, nil
+}
+
+// ValidateState reports ErrStateMismatch unless state is the one of the request.
+func (r *AuthorizationRequest) ValidateState(state string) error {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(r.State)) != 1 {
+		return ErrStateMismatch
+	}
+	return nil
+}
+
+func (a *AuthImpl) GetAccessTokenFromCallback(authRequest *AuthorizationRequest, callback url.Values) (*OAuthResponse, error) {
+	return a.GetAccessTokenFromCallbackContext(context.Background(), authRequest, callback)
+}
+
+// GetAccessTokenFromCallbackContext finishes the authorization code flow with the query
+// parameters of the callback to the redirect URI. It validates the state, returns an
+// *AuthorizationError if the auth server reports one and exchanges the code together with
+// the PKCE code verifier. Like GetAccessTokenFromAuthorizationCode, the tokens are kept.
+func (a *AuthImpl) GetAccessTokenFromCallbackContext(ctx context.Context, authRequest *AuthorizationRequest, callback url.Values) (*OAuthResponse, error) {
+	// the auth server echoes the state on errors too, an error without it may be forged
+	if err := authRequest.ValidateState(callback.Get("state")); err != nil {
+		return nil, err
+	}
+	if code := callback.Get("error"); code != "" {
+		return nil, &AuthorizationError{Code: code, Description: callback.Get("error_description")}
+	}
+	code := callback.Get("code")
+	if code == "" {
+		return nil, ErrMissingCode
+	}
+	return a.exchangeCode(ctx, code, authRequest.CodeVerifier)
+}
+
+// randomString returns 32 random bytes, base64url encoded, as used for state and code verifier.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge derives the S256 PKCE code challenge from verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
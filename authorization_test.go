@@ -0,0 +1,106 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAuthImpl_NewAuthorizationRequest(t *testing.T) {
+	setup()
+	defer teardown()
+
+	authRequest, err := testClient.GetAuthService().NewAuthorizationRequest("read:jira-work", "offline_access")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(authRequest.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Path != "/authorize" {
+		t.Fatal("Wanted path /authorize but got", u.Path)
+	}
+
+	expected := map[string]string{
+		"audience":              "api.atlassian.com",
+		"client_id":             "test",
+		"scope":                 "read:jira-work offline_access",
+		"redirect_uri":          "test",
+		"state":                 authRequest.State,
+		"response_type":         "code",
+		"prompt":                "consent",
+		"code_challenge":        codeChallenge(authRequest.CodeVerifier),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range expected {
+		if u.Query().Get(key) != value {
+			t.Fatal("Wanted", key, value, "but got", u.Query().Get(key))
+		}
+	}
+
+	other, err := testClient.GetAuthService().NewAuthorizationRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.State == authRequest.State || other.CodeVerifier == authRequest.CodeVerifier {
+		t.Fatal("Wanted a fresh state and code verifier for every request")
+	}
+}
+
+func TestAuthImpl_GetAccessTokenFromCallback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	authRequest, err := testClient.GetAuthService().NewAuthorizationRequest("read:jira-work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		var payload OAuthRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Code != "code" || payload.CodeVerifier != authRequest.CodeVerifier {
+			t.Error("Wanted code and code verifier but got", payload.Code, payload.CodeVerifier)
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "access", RefreshToken: "refresh"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	_, err = testClient.GetAuthService().GetAccessTokenFromCallback(authRequest, url.Values{"code": {"code"}, "state": {"forged"}})
+	if !errors.Is(err, ErrStateMismatch) {
+		t.Fatal("Wanted ErrStateMismatch but got", err)
+	}
+
+	_, err = testClient.GetAuthService().GetAccessTokenFromCallback(authRequest, url.Values{"error": {"access_denied"}})
+	if !errors.Is(err, ErrStateMismatch) {
+		t.Fatal("Wanted an error without state to be rejected but got", err)
+	}
+
+	_, err = testClient.GetAuthService().GetAccessTokenFromCallback(authRequest, url.Values{"error": {"access_denied"}, "state": {authRequest.State}})
+	var authErr *AuthorizationError
+	if !errors.As(err, &authErr) || authErr.Code != "access_denied" {
+		t.Fatal("Wanted an access_denied AuthorizationError but got", err)
+	}
+
+	resp, err := testClient.GetAuthService().GetAccessTokenFromCallback(authRequest, url.Values{"code": {"code"}, "state": {authRequest.State}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AccessToken != "access" {
+		t.Fatal("Wanted access but got", resp.AccessToken)
+	}
+
+	if testClient.GetAuthService().GetRefreshToken() != "refresh" {
+		t.Fatal("Wanted the refresh token to be kept but got", testClient.GetAuthService().GetRefreshToken())
+	}
+}
@@ -0,0 +1,106 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// apiGateway is the host OAuth 2.0 (3LO) requests to Jira Cloud go through.
+const apiGateway = "api.atlassian.com"
+
+// ErrSiteNotFound is returned by UseCloudSite when the token has no access to the site.
+var ErrSiteNotFound = errors.New("jira: site not found among the accessible resources")
+
+// AccessibleResource is a site the access token grants access to.
+type AccessibleResource struct {
+	// ID is the cloud ID of the site
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	AvatarURL string   `json:"avatarUrl"`
+}
+
+func (c *client) GetAccessibleResources() ([]AccessibleResource, error) {
+	return c.GetAccessibleResourcesContext(context.Background())
+}
+
+// GetAccessibleResourcesContext lists the sites the access token of the client grants access to.
+// Atlassian API docs: https://developer.atlassian.com/cloud/jira/platform/oauth-2-3lo-apps/#3-1-get-the-cloudid-for-your-site
+func (c *client) GetAccessibleResourcesContext(ctx context.Context) ([]AccessibleResource, error) {
+	u := url.URL{
+		Scheme: c.getScheme(),
+		Host:   c.gatewayURL,
+		Path:   "oauth/token/accessible-resources",
+	}
+
+	req, err := c.newRequestContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	resources := []AccessibleResource{}
+	err = json.NewDecoder(resp.Body).Decode(&resources)
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func (c *client) UseCloudSite(site string) (*AccessibleResource, error) {
+	return c.UseCloudSiteContext(context.Background(), site)
+}
+
+// UseCloudSiteContext looks up the cloud ID of site among the accessible resources and
+// routes every following request through the API gateway to it. site is matched against
+// the name, the URL or host, and the cloud ID of the resources. An empty site stands for
+// the domain the client was created with.
+func (c *client) UseCloudSiteContext(ctx context.Context, site string) (*AccessibleResource, error) {
+	if site == "" {
+		site = c.site
+	}
+	resources, err := c.GetAccessibleResourcesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range resources {
+		if matchesSite(&resources[i], site) {
+			c.routeToCloudID(resources[i].ID)
+			return &resources[i], nil
+		}
+	}
+	return nil, ErrSiteNotFound
+}
+
+// routeToCloudID sends the API requests to ex/jira/{cloudID} on the gateway.
+func (c *client) routeToCloudID(cloudID string) {
+	c.routeMu.Lock()
+	defer c.routeMu.Unlock()
+	c.baseURL = c.gatewayURL
+	c.basePath = "ex/jira/" + cloudID
+}
+
+func matchesSite(resource *AccessibleResource, site string) bool {
+	site = strings.TrimSuffix(strings.ToLower(site), "/")
+	if site == strings.ToLower(resource.ID) || site == strings.ToLower(resource.Name) {
+		return true
+	}
+	resourceURL := strings.TrimSuffix(strings.ToLower(resource.URL), "/")
+	if site == resourceURL {
+		return true
+	}
+	if u, err := url.Parse(resourceURL); err == nil && u.Host == site {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClient_UseCloudSite(t *testing.T) {
+	setup()
+	defer teardown()
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := New("your-domain.atlassian.net",
+		WithScheme("http"),
+		WithAuthDomain(addr),
+		WithAPIGateway(addr),
+		WithOAuthApp("test", "test", "test"),
+	)
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "token"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	testMux.HandleFunc("/oauth/token/accessible-resources", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Error("Wanted the bearer token but got", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode([]AccessibleResource{
+			{ID: "other-id", URL: "https://other.atlassian.net", Name: "other"},
+			{ID: "cloud-id", URL: "https://your-domain.atlassian.net", Name: "your-domain", Scopes: []string{"read:jira-work"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	testMux.HandleFunc("/ex/jira/cloud-id/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&searchResult{Issues: []Issue{{Key: "TEST-1"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	resource, err := c.UseCloudSite("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.ID != "cloud-id" {
+		t.Fatal("Wanted cloud-id but got", resource.ID)
+	}
+
+	issues, err := c.GetIssueService().Search("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Key != "TEST-1" {
+		t.Fatal("Wanted TEST-1 through the gateway but got", issues)
+	}
+
+	if _, err := c.UseCloudSite("unknown"); err != ErrSiteNotFound {
+		t.Fatal("Wanted ErrSiteNotFound but got", err)
+	}
+
+	resource, err = c.UseCloudSite("https://other.atlassian.net/")
+	if err != nil || resource.ID != "other-id" {
+		t.Fatal("Wanted other-id by URL but got", resource, err)
+	}
+}
+
+func TestWithCloudID(t *testing.T) {
+	c := newClient("your-domain.atlassian.net", WithCloudID("cloud-id"))
+
+	u := c.apiURL("rest/api/3/search")
+	if u.String() != "https://api.atlassian.com/ex/jira/cloud-id/rest/api/3/search" {
+		t.Fatal("Wanted the gateway URL but got", u.String())
+	}
+}
@@ -0,0 +1,255 @@
+package jira
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// contextQSH is the qsh of context JWTs, which Connect issues to the browser and which
+// are not bound to a single request.
+const contextQSH = "context-qsh"
+
+// ErrInvalidJWT is returned by ConnectVerifier.Verify when an incoming Connect JWT is
+// missing, malformed, badly signed, expired or bound to another request.
+var ErrInvalidJWT = errors.New("jira: invalid connect jwt")
+
+// ConnectClaims are the claims of an Atlassian Connect JWT.
+type ConnectClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub,omitempty"`
+	Audience  interface{}     `json:"aud,omitempty"`
+	IssuedAt  int64           `json:"iat"`
+	ExpiresAt int64           `json:"exp"`
+	QSH       string          `json:"qsh"`
+	Context   json.RawMessage `json:"context,omitempty"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// ConnectJWT authenticates an Atlassian Connect app: every request carries a short lived
+// HS256 JWT, issued by the app key and bound to the request by its query string hash.
+// Pass it to WithAuthenticator in place of the OAuth 2.0 AuthImpl.
+type ConnectJWT struct {
+	appKey       string
+	sharedSecret []byte
+	basePath     string
+	expiry       time.Duration
+	now          func() time.Time
+}
+
+// NewConnectJWT returns a ConnectJWT for the app appKey with the shared secret Jira sent
+// in the installed lifecycle callback. baseURL is the baseUrl of that callback, its path
+// is left out of the query string hash.
+func NewConnectJWT(appKey string, sharedSecret string, baseURL string) (*ConnectJWT, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &ConnectJWT{
+		appKey:       appKey,
+		sharedSecret: []byte(sharedSecret),
+		basePath:     u.Path,
+		expiry:       3 * time.Minute,
+		now:          time.Now,
+	}, nil
+}
+
+// Authenticate signs req with a JWT for its method, path and query.
+func (j *ConnectJWT) Authenticate(req *http.Request) error {
+	now := j.now()
+	claims := ConnectClaims{
+		Issuer:    j.appKey,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(j.expiry).Unix(),
+		QSH:       QueryStringHash(req.Method, req.URL, j.basePath),
+	}
+	token, err := signJWT(&claims, j.sharedSecret)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "JWT "+token)
+	return nil
+}
+
+// QueryStringHash returns the qsh claim of a request to u, the hex SHA-256 of the
+// canonical request. basePath is the path of the base URL of the Jira site or the app
+// and is not part of the canonical path.
+func QueryStringHash(method string, u *url.URL, basePath string) string {
+	sum := sha256.Sum256([]byte(canonicalRequest(method, u, basePath)))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalRequest builds METHOD&path&query as Connect defines it: the path relative to
+// basePath without a trailing slash, and the query sorted by name with repeated values
+// sorted and joined by commas. The jwt parameter is left out.
+func canonicalRequest(method string, u *url.URL, basePath string) string {
+	p := u.Path
+	basePath = strings.TrimSuffix(basePath, "/")
+	if basePath != "" && strings.HasPrefix(p, basePath) {
+		p = strings.TrimPrefix(p, basePath)
+	}
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	if p == "" {
+		p = "/"
+	}
+	p = strings.ReplaceAll(p, "&", "%26")
+
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if k != "jwt" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	params := make([]string, len(keys))
+	for i, k := range keys {
+		values := make([]string, len(query[k]))
+		for n, v := range query[k] {
+			values[n] = percentEncode(v)
+		}
+		sort.Strings(values)
+		params[i] = percentEncode(k) + "=" + strings.Join(values, ",")
+	}
+
+	return strings.ToUpper(method) + "&" + p + "&" + strings.Join(params, "&")
+}
+
+func signJWT(claims *ConnectClaims, secret []byte) (string, error) {
+	header, err := json.Marshal(&jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(hs256(signingInput, secret)), nil
+}
+
+func hs256(signingInput string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+type connectClaimsKey struct{}
+
+// ConnectClaimsFromContext returns the claims ConnectVerifier.Middleware put into the
+// context of a verified request.
+func ConnectClaimsFromContext(ctx context.Context) (*ConnectClaims, bool) {
+	claims, ok := ctx.Value(connectClaimsKey{}).(*ConnectClaims)
+	return claims, ok
+}
+
+// ConnectVerifier verifies the JWTs Jira sends along with requests to a Connect app,
+// in the Authorization header or the jwt query parameter.
+type ConnectVerifier struct {
+	// SharedSecret returns the shared secret of the Jira site identified by the client
+	// key in the iss claim, as stored by the installed lifecycle callback.
+	SharedSecret func(ctx context.Context, clientKey string) ([]byte, error)
+	// BasePath is the path of the base URL of the app, it is not part of the qsh.
+	BasePath string
+	// AllowContextQSH accepts context JWTs, which carry the fixed qsh "context-qsh"
+	// instead of a hash of the request.
+	AllowContextQSH bool
+	// Leeway tolerates clock skew when checking the expiry.
+	Leeway time.Duration
+
+	now func() time.Time
+}
+
+// Middleware verifies every request before passing it on to next, with the claims in
+// the request context. Requests that fail verification get a 401.
+func (v *ConnectVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := v.Verify(r)
+		if err != nil {
+			http.Error(w, "invalid jwt", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), connectClaimsKey{}, claims)))
+	})
+}
+
+// Verify checks the signature, expiry and query string hash of the JWT of r and returns
+// its claims. Failures match ErrInvalidJWT with errors.Is.
+func (v *ConnectVerifier) Verify(r *http.Request) (*ConnectClaims, error) {
+	token := r.URL.Query().Get("jwt")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "JWT ") {
+		token = strings.TrimPrefix(auth, "JWT ")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("%w: no token", ErrInvalidJWT)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidJWT)
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidJWT, header.Alg)
+	}
+	var claims ConnectClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	secret, err := v.SharedSecret(r.Context(), claims.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown issuer %q: %v", ErrInvalidJWT, claims.Issuer, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, hs256(parts[0]+"."+parts[1], secret)) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidJWT)
+	}
+
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	if now().Add(-v.Leeway).Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidJWT)
+	}
+
+	if claims.QSH == contextQSH {
+		if !v.AllowContextQSH {
+			return nil, fmt.Errorf("%w: context jwt not allowed", ErrInvalidJWT)
+		}
+	} else if !hmac.Equal([]byte(claims.QSH), []byte(QueryStringHash(r.Method, r.URL, v.BasePath))) {
+		return nil, fmt.Errorf("%w: qsh does not match the request", ErrInvalidJWT)
+	}
+	return &claims, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+	}
+	return nil
+}
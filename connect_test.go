@@ -0,0 +1,127 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalRequest(t *testing.T) {
+	tests := []struct {
+		method, url, basePath, want string
+	}{
+		{"get", "https://example.atlassian.net/rest/api/3/search?jql=project%20%3D%20A&fields=summary&fields=assignee&jwt=x", "",
+			"GET&/rest/api/3/search&fields=assignee,summary&jql=project%20%3D%20A"},
+		{"POST", "https://example.com/jira/rest/api/2/issue/A&B/", "/jira/", "POST&/rest/api/2/issue/A%26B&"},
+		{"GET", "https://example.com/jira", "/jira", "GET&/&"},
+		{"GET", "https://example.com/?a=1,2&a=0&b~=*", "", "GET&/&a=0,1%2C2&b~=%2A"},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := canonicalRequest(test.method, u, test.basePath); got != test.want {
+			t.Fatal("Wanted", test.want, "but got", got)
+		}
+	}
+}
+
+func TestConnectJWT(t *testing.T) {
+	setup()
+	defer teardown()
+
+	verifier := &ConnectVerifier{
+		SharedSecret: func(ctx context.Context, clientKey string) ([]byte, error) {
+			if clientKey != "my-app" {
+				return nil, errors.New("unknown client")
+			}
+			return []byte("shared-secret"), nil
+		},
+		BasePath: "/jira",
+	}
+	testMux.Handle("/jira/rest/api/3/search", verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ConnectClaimsFromContext(r.Context())
+		if !ok || claims.Issuer != "my-app" {
+			t.Error("Wanted the claims in the context but got", claims)
+		}
+		_, _ = w.Write([]byte(`{"issues":[{"key":"TEST-1"}]}`))
+	})))
+
+	auth, err := NewConnectJWT("my-app", "shared-secret", testServer.URL+"/jira")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := New(addr, WithScheme("http"), WithBasePath("jira"), WithAuthenticator(auth))
+	issues, err := c.GetIssueService().Search("project = TEST", &SearchOptions{Fields: []string{"summary"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 {
+		t.Fatal("Wanted 1 issue but got", len(issues))
+	}
+
+	wrongSecret, err := NewConnectJWT("my-app", "other-secret", testServer.URL+"/jira")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = New(addr, WithScheme("http"), WithBasePath("jira"), WithAuthenticator(wrongSecret))
+	if _, err := c.GetIssueService().Search("project = TEST", nil); !errors.Is(err, ErrUnauthorized) {
+		t.Fatal("Wanted ErrUnauthorized but got", err)
+	}
+}
+
+func TestConnectVerifier(t *testing.T) {
+	verifier := &ConnectVerifier{
+		SharedSecret: func(ctx context.Context, clientKey string) ([]byte, error) {
+			return []byte("shared-secret"), nil
+		},
+	}
+	now := time.Unix(1600000000, 0)
+	sign := func(qsh string, exp time.Time) string {
+		token, err := signJWT(&ConnectClaims{Issuer: "jira-site", IssuedAt: now.Unix(), ExpiresAt: exp.Unix(), QSH: qsh}, []byte("shared-secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	request := func(target string, token string) *http.Request {
+		r := httptest.NewRequest("GET", target, nil)
+		r.Header.Set("Authorization", "JWT "+token)
+		return r
+	}
+	verifier.now = func() time.Time { return now }
+
+	u, _ := url.Parse("/installed?a=1")
+	valid := sign(QueryStringHash("GET", u, ""), now.Add(time.Minute))
+	if _, err := verifier.Verify(request("/installed?a=1", valid)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(request("/installed?a=2", valid)); !errors.Is(err, ErrInvalidJWT) {
+		t.Fatal("Wanted ErrInvalidJWT for another query but got", err)
+	}
+	if _, err := verifier.Verify(request("/installed?a=1", valid[:len(valid)-2])); !errors.Is(err, ErrInvalidJWT) {
+		t.Fatal("Wanted ErrInvalidJWT for a bad signature but got", err)
+	}
+
+	expired := sign(QueryStringHash("GET", u, ""), now.Add(-time.Minute))
+	if _, err := verifier.Verify(request("/installed?a=1", expired)); !errors.Is(err, ErrInvalidJWT) {
+		t.Fatal("Wanted ErrInvalidJWT for an expired token but got", err)
+	}
+
+	contextToken := sign(contextQSH, now.Add(time.Minute))
+	if _, err := verifier.Verify(request("/panel", contextToken)); !errors.Is(err, ErrInvalidJWT) {
+		t.Fatal("Wanted ErrInvalidJWT for a context jwt but got", err)
+	}
+	verifier.AllowContextQSH = true
+	r := httptest.NewRequest("GET", "/panel?jwt="+contextToken, nil)
+	if _, err := verifier.Verify(r); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,184 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrBadRequest is matched by errors for 400 Bad Request responses, e.g. invalid JQL.
+	ErrBadRequest = errors.New("jira: bad request")
+
+	// ErrUnauthorized is matched by errors for 401 Unauthorized responses and by a
+	// RefreshError when the auth server refused to refresh the access token. Either way
+	// the session is gone, usually the refresh token was revoked or expired and the user
+	// has to authorize the app again.
+	ErrUnauthorized = errors.New("jira: unauthorized")
+
+	// ErrForbidden is matched by errors for 403 Forbidden responses.
+	ErrForbidden = errors.New("jira: forbidden")
+
+	// ErrNotFound is matched by errors for 404 Not Found responses. Jira also answers
+	// with 404 when the user is not allowed to browse the resource.
+	ErrNotFound = errors.New("jira: not found")
+
+	// ErrRateLimited is matched by errors for 429 Too Many Requests responses.
+	ErrRateLimited = errors.New("jira: rate limited")
+)
+
+// RefreshError is returned for a request when the auth server refused to refresh the
+// access token, usually because the refresh token was revoked or expired and the user has
+// to authorize the app again. It matches ErrUnauthorized, errors.As reaches the *Error
+// of the auth server.
+type RefreshError struct {
+	Err error
+}
+
+func (e *RefreshError) Error() string {
+	return "jira: refreshing the access token failed: " + e.Err.Error()
+}
+
+func (e *RefreshError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes RefreshError match ErrUnauthorized.
+func (e *RefreshError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// Error represents an error response of the Jira REST API or the Atlassian auth server.
+// Use errors.Is with the sentinel errors of this package to branch on the status code
+// and errors.As to get to the details:
+//
+//	var jiraErr *jira.Error
+//	if errors.As(err, &jiraErr) {
+//		log.Println(jiraErr.ErrorMessages, jiraErr.Errors)
+//	}
+type Error struct {
+	StatusCode int
+	Method     string
+	URL        string
+	// ErrorMessages holds the general messages Jira sends in "errorMessages"
+	ErrorMessages []string
+	// Errors maps field names to the validation message Jira sends in "errors"
+	Errors map[string]string
+	// Body is the decoded JSON error body, if it was JSON at all
+	Body      map[string]interface{}
+	RateLimit RateLimit
+}
+
+// RateLimit holds the rate limit headers Jira Cloud sends along with a response.
+type RateLimit struct {
+	// RetryAfter is how long Jira asks the client to wait, from the Retry-After header
+	RetryAfter time.Duration
+	// Limit is the X-RateLimit-Limit header, zero if absent
+	Limit int
+	// Remaining is the X-RateLimit-Remaining header, -1 if absent
+	Remaining int
+	// Reset is the X-RateLimit-Reset header, the zero time if absent
+	Reset time.Time
+}
+
+// errorBody is the JSON body Jira sends with 4xx and 5xx responses.
+// The auth server uses the OAuth error and error_description fields instead.
+type errorBody struct {
+	ErrorMessages    []string          `json:"errorMessages"`
+	Errors           map[string]string `json:"errors"`
+	Error            string            `json:"error"`
+	ErrorDescription string            `json:"error_description"`
+}
+
+// newError builds an Error from a failed response and its already read body.
+func newError(resp *http.Response, body []byte) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		RateLimit:  parseRateLimit(resp.Header),
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.String()
+	}
+
+	var v errorBody
+	if json.Unmarshal(body, &v) == nil {
+		e.ErrorMessages = v.ErrorMessages
+		e.Errors = v.Errors
+		if v.ErrorDescription != "" {
+			e.ErrorMessages = append(e.ErrorMessages, v.ErrorDescription)
+		} else if v.Error != "" {
+			e.ErrorMessages = append(e.ErrorMessages, v.Error)
+		}
+		_ = json.Unmarshal(body, &e.Body)
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("jira: ")
+	if e.Method != "" {
+		b.WriteString(e.Method + " " + e.URL + ": ")
+	}
+	b.WriteString(strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode))
+
+	messages := append([]string{}, e.ErrorMessages...)
+	// sorted so the same error always reads the same
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		messages = append(messages, field+": "+e.Errors[field])
+	}
+	if len(messages) > 0 {
+		b.WriteString(": " + strings.Join(messages, "; "))
+	}
+	return b.String()
+}
+
+// Is reports whether the error matches one of the sentinel errors of this package.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+func parseRateLimit(h http.Header) RateLimit {
+	rl := RateLimit{Remaining: -1}
+	if v := h.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			rl.RetryAfter = time.Duration(seconds) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			rl.RetryAfter = time.Until(t)
+		}
+	}
+	if v, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		rl.Limit = v
+	}
+	if v, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		rl.Remaining = v
+	}
+	if v := h.Get("X-RateLimit-Reset"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			rl.Reset = t
+		}
+	}
+	return rl
+}
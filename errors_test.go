@@ -0,0 +1,111 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestError_FromResponse(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		resp := OAuthResponse{AccessToken: "token"}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	testMux.HandleFunc("/rest/api/3/issue/TEST-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.WriteHeader(404)
+		_, err := w.Write([]byte(`{"errorMessages":["Issue does not exist or you do not have permission to see it."],"errors":{"key":"invalid"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	url := testClient.getScheme() + "://" + testClient.getBaseURL() + "/rest/api/3/issue/TEST-1"
+	req, err := testClient.newRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testClient.sendRequest(req)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal("Wanted ErrNotFound but got", err)
+	}
+
+	if errors.Is(err, ErrForbidden) {
+		t.Fatal("Did not want ErrForbidden to match a 404")
+	}
+
+	var jiraErr *Error
+	if !errors.As(err, &jiraErr) {
+		t.Fatal("Wanted a *Error but got", err)
+	}
+
+	if jiraErr.StatusCode != 404 {
+		t.Fatal("Wanted status 404 but got", jiraErr.StatusCode)
+	}
+
+	if jiraErr.Method != http.MethodGet || jiraErr.URL != url {
+		t.Fatal("Wanted", http.MethodGet, url, "but got", jiraErr.Method, jiraErr.URL)
+	}
+
+	if len(jiraErr.ErrorMessages) != 1 || jiraErr.Errors["key"] != "invalid" {
+		t.Fatal("Wanted the Jira error messages but got", jiraErr.ErrorMessages, jiraErr.Errors)
+	}
+
+	if jiraErr.RateLimit.Limit != 100 || jiraErr.RateLimit.Remaining != 42 {
+		t.Fatal("Wanted rate limit 100/42 but got", jiraErr.RateLimit.Limit, jiraErr.RateLimit.Remaining)
+	}
+
+	if !strings.Contains(err.Error(), "Issue does not exist") {
+		t.Fatal("Wanted the error message in", err.Error())
+	}
+}
+
+func TestError_AuthServer(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403)
+		_, err := w.Write([]byte(`{"error":"invalid_grant","error_description":"Unknown or invalid refresh token."}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	_, err := testClient.GetAuthService().GetAccessTokenFromRefreshToken()
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatal("Wanted ErrForbidden but got", err)
+	}
+
+	var jiraErr *Error
+	if !errors.As(err, &jiraErr) || len(jiraErr.ErrorMessages) != 1 || jiraErr.ErrorMessages[0] != "Unknown or invalid refresh token." {
+		t.Fatal("Wanted the OAuth error description but got", err)
+	}
+}
+
+func TestError_FieldOrder(t *testing.T) {
+	err := &Error{
+		StatusCode:    400,
+		ErrorMessages: []string{"Invalid input."},
+		Errors:        map[string]string{"summary": "required", "assignee": "unknown", "priority": "invalid"},
+	}
+
+	expected := "jira: 400 Bad Request: Invalid input.; assignee: unknown; priority: invalid; summary: required"
+	for i := 0; i < 10; i++ {
+		if err.Error() != expected {
+			t.Fatal("Wanted", expected, "but got", err.Error())
+		}
+	}
+}
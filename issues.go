@@ -1,10 +1,9 @@
 package jira
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/url"
 	"strconv"
 	"strings"
@@ -15,116 +14,292 @@ type IssueImpl struct {
 }
 
 type IssueService interface {
+	Get(key string, options *GetQueryOptions) (*Issue, error)
+	GetContext(ctx context.Context, key string, options *GetQueryOptions) (*Issue, error)
 	Search(jql string, options *SearchOptions) ([]Issue, error)
+	SearchContext(ctx context.Context, jql string, options *SearchOptions) ([]Issue, error)
+	SearchWithMetadata(jql string, options *SearchOptions) (*SearchResult, error)
+	SearchWithMetadataContext(ctx context.Context, jql string, options *SearchOptions) (*SearchResult, error)
+	SearchAll(jql string, options *SearchOptions, limit int) ([]Issue, error)
+	SearchAllContext(ctx context.Context, jql string, options *SearchOptions, limit int) ([]Issue, error)
+	SearchIterator(jql string, options *SearchOptions, limit int) *SearchIterator
+	SearchIteratorContext(ctx context.Context, jql string, options *SearchOptions, limit int) *SearchIterator
 	Update(key string, timeSpent string) error
+	UpdateContext(ctx context.Context, key string, timeSpent string) error
 }
 
-// Jira API docs: https://developer.atlassian.com/jiradev/jira-apis/jira-rest-apis/jira-rest-api-tutorials/jira-rest-api-example-query-issues
-func (i *IssueImpl) Search(jql string, options *SearchOptions) ([]Issue, error) {
-	var v searchResult
-	v.Issues = []Issue{}
-	log.Println("[Search] Starting")
-	for attempt := attempts.Start(nil); attempt.Next(); {
-		log.Println("[Search] Starting Attempt:" + strconv.FormatInt(int64(attempt.Count()), 10))
-		u := url.URL{
-			Scheme: i.client.getScheme(),
-			Host:   i.client.getBaseURL(),
-			Path:   "rest/api/3/search",
+// Get returns the issue with the key or ID key. If the issue does not exist or the user
+// cannot browse it, the error matches ErrNotFound. An empty key is ErrEmptyID.
+//
+// Jira API docs: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-issueidorkey-get
+func (i *IssueImpl) Get(key string, options *GetQueryOptions) (*Issue, error) {
+	return i.GetContext(context.Background(), key, options)
+}
+
+// GetContext is like Get but cancels the request when ctx is done.
+func (i *IssueImpl) GetContext(ctx context.Context, key string, options *GetQueryOptions) (*Issue, error) {
+	if err := i.client.checkScopes("IssueService.Get"); err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, fmt.Errorf("%w: issue key", ErrEmptyID)
+	}
+	u := i.client.restURL("issue/" + pathSegment(key))
+	uv := url.Values{}
+
+	if options != nil {
+		if len(options.Fields) > 0 {
+			uv.Add("fields", strings.Join(options.Fields, ","))
 		}
-		uv := url.Values{}
-		if jql != "" {
-			uv.Add("jql", jql)
+		if options.Expand != "" {
+			uv.Add("expand", options.Expand)
 		}
-
-		if options != nil {
-			if options.StartAt != 0 {
-				uv.Add("startAt", strconv.Itoa(options.StartAt))
-			}
-			if options.MaxResults != 0 {
-				uv.Add("maxResults", strconv.Itoa(options.MaxResults))
-			}
-			if options.Expand != "" {
-				uv.Add("expand", options.Expand)
-			}
-			if strings.Join(options.Fields, ",") != "" {
-				uv.Add("fields", strings.Join(options.Fields, ","))
-			}
-			if options.ValidateQuery != "" {
-				uv.Add("validateQuery", options.ValidateQuery)
-			}
+		if len(options.Properties) > 0 {
+			uv.Add("properties", strings.Join(options.Properties, ","))
 		}
+		if options.UpdateHistory {
+			uv.Add("updateHistory", "true")
+		}
+	}
 
-		method := "GET"
-		u.RawQuery = uv.Encode()
+	method := "GET"
+	u.RawQuery = uv.Encode()
 
-		req, err := i.client.newRequest(method, u.String(), nil)
-		if err != nil {
-			return nil, err
-		}
+	req, err := i.client.newRequestContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
 
-		resp, err := i.client.sendRequest(req)
-		if err != nil {
-			return nil, err
-		}
+	resp, err := i.client.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-		err = json.NewDecoder(resp.Body).Decode(&v)
-		if err != nil {
-			return nil, err
-		}
+	var v Issue
+	err = json.NewDecoder(resp.Body).Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
 
-		// retry here
-		if len(v.Issues) == 0 {
-			// refresh token
-			resp, err := i.client.GetAuthService().GetAccessTokenFromRefreshToken()
-			if err != nil {
-				return nil, err
-			}
-			i.client.GetAuthService().SetAccessToken(resp.AccessToken)
-			continue
-		}
-		break
+// Jira API docs: https://developer.atlassian.com/jiradev/jira-apis/jira-rest-apis/jira-rest-api-tutorials/jira-rest-api-example-query-issues
+func (i *IssueImpl) Search(jql string, options *SearchOptions) ([]Issue, error) {
+	return i.SearchContext(context.Background(), jql, options)
+}
+
+// SearchContext is like Search but cancels the request when ctx is done.
+func (i *IssueImpl) SearchContext(ctx context.Context, jql string, options *SearchOptions) ([]Issue, error) {
+	v, err := i.searchPage(ctx, jql, options)
+	if err != nil {
+		return nil, err
 	}
-	log.Println("[Search] Ending")
 	return v.Issues, nil
 }
 
-func (i *IssueImpl) Update(key string, timeSpent string) error {
+// SearchWithMetadata sends a single search request and returns the page of issues
+// together with the paging metadata, the names and schema maps and any warning messages.
+func (i *IssueImpl) SearchWithMetadata(jql string, options *SearchOptions) (*SearchResult, error) {
+	return i.SearchWithMetadataContext(context.Background(), jql, options)
+}
 
-	log.Println("[Update] Starting")
+// SearchWithMetadataContext is like SearchWithMetadata but cancels the request when ctx is done.
+func (i *IssueImpl) SearchWithMetadataContext(ctx context.Context, jql string, options *SearchOptions) (*SearchResult, error) {
+	v, err := i.searchPage(ctx, jql, options)
+	if err != nil {
+		return nil, err
+	}
+	return (*SearchResult)(v), nil
+}
+
+// SearchAll walks every page of the JQL search and returns all matching issues.
+// options.StartAt is used as the first offset and options.MaxResults as the page size.
+// If limit is greater than zero, at most limit issues are returned.
+func (i *IssueImpl) SearchAll(jql string, options *SearchOptions, limit int) ([]Issue, error) {
+	return i.SearchAllContext(context.Background(), jql, options, limit)
+}
+
+// SearchAllContext is like SearchAll but stops paging when ctx is done.
+func (i *IssueImpl) SearchAllContext(ctx context.Context, jql string, options *SearchOptions, limit int) ([]Issue, error) {
+	issues := []Issue{}
+	it := i.SearchIteratorContext(ctx, jql, options, limit)
+	for it.Next() {
+		issues = append(issues, it.Issue())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
 
-	pathWithKey := fmt.Sprintf("rest/api/3/issue/%v/worklog", key)
+// SearchIterator returns an iterator that lazily fetches the pages of the JQL search.
+// If limit is greater than zero, the iterator stops after limit issues.
+func (i *IssueImpl) SearchIterator(jql string, options *SearchOptions, limit int) *SearchIterator {
+	return i.SearchIteratorContext(context.Background(), jql, options, limit)
+}
 
-	log.Println("path", pathWithKey)
-	u := url.URL{
-		Scheme: i.client.getScheme(),
-		Host:   i.client.getBaseURL(),
-		Path:   pathWithKey,
+// SearchIteratorContext is like SearchIterator but uses ctx for every page request.
+func (i *IssueImpl) SearchIteratorContext(ctx context.Context, jql string, options *SearchOptions, limit int) *SearchIterator {
+	it := &SearchIterator{
+		ctx:     ctx,
+		service: i,
+		jql:     jql,
+		limit:   limit,
+	}
+	if options != nil {
+		it.options = *options
 	}
+	return it
+}
 
+// searchPage sends a single search request and decodes one page of results.
+func (i *IssueImpl) searchPage(ctx context.Context, jql string, options *SearchOptions) (*searchResult, error) {
+	if err := i.client.checkScopes("IssueService.Search"); err != nil {
+		return nil, err
+	}
+	u := i.client.restURL("search")
 	uv := url.Values{}
-	method := "POST"
-	u.RawQuery = uv.Encode()
+	if jql != "" {
+		uv.Add("jql", jql)
+	}
 
-	var workLog WorkLog
+	if options != nil {
+		if options.StartAt != 0 {
+			uv.Add("startAt", strconv.Itoa(options.StartAt))
+		}
+		if options.MaxResults != 0 {
+			uv.Add("maxResults", strconv.Itoa(options.MaxResults))
+		}
+		if options.Expand != "" {
+			uv.Add("expand", options.Expand)
+		}
+		if strings.Join(options.Fields, ",") != "" {
+			uv.Add("fields", strings.Join(options.Fields, ","))
+		}
+		if options.ValidateQuery != "" {
+			uv.Add("validateQuery", options.ValidateQuery)
+		}
+	}
 
-	workLog.TimeSpent = timeSpent
+	method := "GET"
+	u.RawQuery = uv.Encode()
 
-	requestBody, err := json.Marshal(&workLog)
+	req, err := i.client.newRequestContext(ctx, method, u.String(), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	req, err := i.client.newRequest(method, u.String(), bytes.NewBuffer(requestBody))
+	resp, err := i.client.sendRequest(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	resp, err := i.client.sendRequest(req)
+	v := searchResult{Issues: []Issue{}}
+	err = json.NewDecoder(resp.Body).Decode(&v)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Update logs timeSpent, e.g. "3h 20m", on the issue.
+//
+// Deprecated: Update only sets the time spent, use WorklogService.Add instead.
+func (i *IssueImpl) Update(key string, timeSpent string) error {
+	return i.UpdateContext(context.Background(), key, timeSpent)
+}
+
+// UpdateContext is like Update but cancels the request when ctx is done.
+//
+// Deprecated: use WorklogService.AddContext instead.
+func (i *IssueImpl) UpdateContext(ctx context.Context, key string, timeSpent string) error {
+	_, err := i.client.worklogService.AddContext(ctx, key, &WorkLog{TimeSpent: timeSpent}, nil)
+	return err
+}
+
+// SearchIterator pages through the results of a JQL search.
+// Call Next until it returns false, then check Err:
+//
+//	it := issueService.SearchIterator("project = ABC", nil, 0)
+//	for it.Next() {
+//		issue := it.Issue()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type SearchIterator struct {
+	ctx     context.Context
+	service *IssueImpl
+	jql     string
+	options SearchOptions
+	limit   int
+
+	page    []Issue
+	index   int
+	count   int
+	total   int
+	done    bool
+	current Issue
+	err     error
+}
+
+// Next advances the iterator to the next issue, fetching the next page when
+// the current one is exhausted. It returns false when all issues were read,
+// the limit was reached or an error occurred.
+func (it *SearchIterator) Next() bool {
+	if it.err != nil || (it.limit > 0 && it.count >= it.limit) {
+		return false
 	}
+	for it.index >= len(it.page) {
+		if it.done {
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	it.current = it.page[it.index]
+	it.index++
+	it.count++
+	return true
+}
 
-	log.Println(resp)
-	log.Println("[Update] Ending")
+// Issue returns the issue the iterator currently points at.
+func (it *SearchIterator) Issue() Issue {
+	return it.current
+}
+
+// Total returns the total number of issues matching the query as reported by Jira.
+// It is zero until the first page has been fetched.
+func (it *SearchIterator) Total() int {
+	return it.total
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *SearchIterator) Err() error {
+	return it.err
+}
+
+func (it *SearchIterator) fetch() error {
+	options := it.options
+	// do not fetch more issues than the limit leaves room for
+	if remaining := it.limit - it.count; it.limit > 0 && (options.MaxResults == 0 || options.MaxResults > remaining) {
+		options.MaxResults = remaining
+	}
+	v, err := it.service.searchPage(it.ctx, it.jql, &options)
+	if err != nil {
+		return err
+	}
+	it.total = v.Total
+	it.page = v.Issues
+	it.index = 0
+	it.options.StartAt = v.StartAt + len(v.Issues)
+	// an empty page means Jira has nothing more to give, even if total says otherwise
+	if len(v.Issues) == 0 || it.options.StartAt >= v.Total {
+		it.done = true
+	}
 	return nil
 }
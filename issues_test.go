@@ -2,7 +2,10 @@ package jira
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -72,3 +75,275 @@ func TestClient_Search(t *testing.T) {
 		t.Fatal("Expected Self", expected.Issues[0].Self, "but got", actual[0].Self)
 	}
 }
+
+func TestClient_SearchAll(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		resp := OAuthResponse{}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	total := 5
+	requests := 0
+	var pageSizes []string
+	testMux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		pageSizes = append(pageSizes, r.URL.Query().Get("maxResults"))
+		maxResults, err := strconv.Atoi(r.URL.Query().Get("maxResults"))
+		if err != nil {
+			maxResults = 50
+		}
+		page := searchResult{StartAt: startAt, MaxResults: maxResults, Total: total}
+		for i := startAt; i < startAt+maxResults && i < total; i++ {
+			page.Issues = append(page.Issues, Issue{Key: "TEST-" + strconv.Itoa(i)})
+		}
+		w.WriteHeader(200)
+		err = json.NewEncoder(w).Encode(&page)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	actual, err := testClient.GetIssueService().SearchAll("test", &SearchOptions{MaxResults: 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actual) != total {
+		t.Fatal("Expected", total, "issues but got", len(actual))
+	}
+
+	if requests != 3 {
+		t.Fatal("Expected 3 requests but got", requests)
+	}
+	if strings.Join(pageSizes, ",") != "2,2,2" {
+		t.Fatal("Expected maxResults 2 on every page but got", pageSizes)
+	}
+
+	for i, issue := range actual {
+		if issue.Key != "TEST-"+strconv.Itoa(i) {
+			t.Fatal("Expected Key", "TEST-"+strconv.Itoa(i), "but got", issue.Key)
+		}
+	}
+
+	requests = 0
+	actual, err = testClient.GetIssueService().SearchAll("test", &SearchOptions{MaxResults: 2}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actual) != 3 {
+		t.Fatal("Expected 3 issues but got", len(actual))
+	}
+
+	if requests != 2 {
+		t.Fatal("Expected 2 requests but got", requests)
+	}
+	if strings.Join(pageSizes[3:], ",") != "2,1" {
+		t.Fatal("Expected the last page to only fetch the remaining issue but got", pageSizes[3:])
+	}
+
+	// without a page size only the limit is fetched
+	pageSizes = nil
+	actual, err = testClient.GetIssueService().SearchAll("test", nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 3 || strings.Join(pageSizes, ",") != "3" {
+		t.Fatal("Expected 3 issues from one page of 3 but got", len(actual), "from", pageSizes)
+	}
+}
+
+func TestClient_SearchWithMetadata(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		resp := OAuthResponse{}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	expected := searchResult{
+		Issues:          []Issue{{Key: "TEST-1"}},
+		StartAt:         10,
+		MaxResults:      1,
+		Total:           42,
+		Names:           map[string]string{"summary": "Summary"},
+		Schema:          map[string]FieldSchema{"summary": {Type: "string", System: "summary"}},
+		WarningMessages: []string{"The value 'foo' does not exist for the field 'project'."},
+	}
+
+	testMux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("expand") != "names,schema" {
+			t.Fatal("Expected expand names,schema but got", r.URL.Query().Get("expand"))
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	actual, err := testClient.GetIssueService().SearchWithMetadata("test", &SearchOptions{StartAt: 10, MaxResults: 1, Expand: "names,schema"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual.Total != expected.Total || actual.StartAt != expected.StartAt || actual.MaxResults != expected.MaxResults {
+		t.Fatal("Expected paging", expected.StartAt, expected.MaxResults, expected.Total, "but got", actual.StartAt, actual.MaxResults, actual.Total)
+	}
+
+	if actual.Names["summary"] != "Summary" {
+		t.Fatal("Expected name Summary but got", actual.Names["summary"])
+	}
+
+	if actual.Schema["summary"].Type != "string" {
+		t.Fatal("Expected schema type string but got", actual.Schema["summary"].Type)
+	}
+
+	if len(actual.WarningMessages) != 1 || actual.WarningMessages[0] != expected.WarningMessages[0] {
+		t.Fatal("Expected warnings", expected.WarningMessages, "but got", actual.WarningMessages)
+	}
+}
+
+func TestClient_SearchNoMatches(t *testing.T) {
+	setup()
+	defer teardown()
+
+	refreshes := 0
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		refreshes++
+		resp := OAuthResponse{AccessToken: "token"}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	testMux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&searchResult{Issues: []Issue{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	actual, err := testClient.GetIssueService().Search("project = EMPTY", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual == nil || len(actual) != 0 {
+		t.Fatal("Expected an empty issues list but got", actual)
+	}
+
+	if refreshes != 1 {
+		t.Fatal("Expected 1 token refresh but got", refreshes)
+	}
+}
+
+func TestClient_GetIssue(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	testMux.HandleFunc("/rest/api/3/issue/TEST-1", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("fields") != "summary,status" || q.Get("expand") != "changelog,names" || q.Get("properties") != "flag" || q.Get("updateHistory") != "true" {
+			t.Error("Wanted the get options in the query but got", r.URL.RawQuery)
+		}
+		w.WriteHeader(200)
+		_, err := w.Write([]byte(`{"id":"10001","key":"TEST-1","fields":{"summary":"Get it",` +
+			`"description":{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"Line one"},{"type":"hardBreak"},{"type":"text","text":"line two"}]}]},` +
+			`"comment":{"comments":[{"id":"1","body":{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"Looks good"}]}]}}]}},` +
+			`"names":{"summary":"Summary"},"properties":{"flag":{"on":true}}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	testMux.HandleFunc("/rest/api/3/issue/TEST-2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		_, _ = w.Write([]byte(`{"errorMessages":["Issue does not exist or you do not have permission to see it."],"errors":{}}`))
+	})
+
+	issue, err := testClient.GetIssueService().Get("TEST-1", &GetQueryOptions{
+		Fields:        []string{"summary", "status"},
+		Expand:        "changelog,names",
+		Properties:    []string{"flag"},
+		UpdateHistory: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.Key != "TEST-1" || issue.Fields.Summary != "Get it" || issue.Names["summary"] != "Summary" {
+		t.Fatal("Wanted TEST-1 with summary and names but got", issue)
+	}
+	if issue.Fields.Description != "Line one\nline two" {
+		t.Fatal("Wanted the text of the ADF description but got", issue.Fields.Description)
+	}
+	if comments := issue.Fields.Comments; comments == nil || len(comments.Comments) != 1 || comments.Comments[0].Body != "Looks good" {
+		t.Fatal("Wanted the text of the ADF comment but got", comments)
+	}
+	if flag, ok := issue.Properties["flag"].(map[string]interface{}); !ok || flag["on"] != true {
+		t.Fatal("Wanted the flag property but got", issue.Properties)
+	}
+
+	_, err = testClient.GetIssueService().Get("TEST-2", nil)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal("Wanted ErrNotFound but got", err)
+	}
+	var jiraErr *Error
+	if !errors.As(err, &jiraErr) || len(jiraErr.ErrorMessages) != 1 {
+		t.Fatal("Wanted the error messages of Jira but got", err)
+	}
+}
+
+func TestClient_GetIssueEmptyKey(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	testMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Wanted no request but got", r.Method, r.URL.Path)
+	})
+
+	if _, err := testClient.GetIssueService().Get("", nil); !errors.Is(err, ErrEmptyID) {
+		t.Fatal("Wanted ErrEmptyID but got", err)
+	}
+}
+
+func TestClient_GetIssueEscapesKey(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	testMux.HandleFunc("/rest/api/3/myself", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("Did not want the key to reach another resource")
+	})
+	testMux.HandleFunc("/rest/api/3/issue/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/rest/api/3/issue/..%2F..%2F..%2Frest%2Fapi%2F3%2Fmyself" {
+			t.Error("Wanted the key as one escaped segment but got", r.URL.EscapedPath())
+		}
+		w.WriteHeader(404)
+	})
+
+	_, err := testClient.GetIssueService().Get("../../../rest/api/3/myself", nil)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal("Wanted ErrNotFound but got", err)
+	}
+	if got := pathSegment(".."); got != "%2E%2E" {
+		t.Fatal("Wanted the dot segment to be escaped but got", got)
+	}
+}
@@ -1,12 +1,20 @@
 package jira
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
 	"time"
 
 	"gopkg.in/retry.v1"
 )
 
 type client struct {
+	// routeMu guards baseURL and basePath, which UseCloudSite rewrites
+	routeMu      sync.RWMutex
 	baseURL      string
 	authURL      string
 	scheme       string
@@ -14,38 +22,109 @@ type client struct {
 	clientSecret string
 	redirectURI  string
 
-	authService  AuthService
-	issueService IssueService
+	basePath      string
+	apiVersion    string
+	deployment    Deployment
+	site          string
+	gatewayURL    string
+	userAgent     string
+	httpClient    *http.Client
+	retryStrategy retry.Strategy
+	logger        Logger
+	limiter       RateLimiter
+	now           func() time.Time
+
+	authenticator  Authenticator
+	authService    AuthService
+	issueService   IssueService
+	worklogService WorklogService
 }
 
+// Deployment is the kind of Jira installation the client talks to.
+type Deployment int
+
+const (
+	// DeploymentCloud is Jira Cloud, users are identified by account ID
+	DeploymentCloud Deployment = iota
+	// DeploymentServer is Jira Server or Data Center, users are identified by username
+	DeploymentServer
+)
+
 type Client interface {
+	GetDeployment() Deployment
+	GetAuthenticator() Authenticator
 	GetAuthService() AuthService
 	GetIssueService() IssueService
+	GetWorklogService() WorklogService
+	GetAccessibleResources() ([]AccessibleResource, error)
+	GetAccessibleResourcesContext(ctx context.Context) ([]AccessibleResource, error)
+	UseCloudSite(site string) (*AccessibleResource, error)
+	UseCloudSiteContext(ctx context.Context, site string) (*AccessibleResource, error)
 }
 
-var attempts = retry.Regular{
-	Total: 1 * time.Second,
-	Delay: 250 * time.Millisecond,
-	Min:   2,
+// attempts is the retry strategy of sendRequest: up to five tries with
+// jittered exponential backoff between them.
+var attempts = retry.LimitCount(5, retry.Exponential{
+	Initial:  250 * time.Millisecond,
+	Factor:   2,
+	MaxDelay: 10 * time.Second,
+	Jitter:   true,
+})
+
+// NewClient returns a client for the Jira site at domain that authenticates with the
+// OAuth 2.0 app given by clientID, clientSecret and redirectURI.
+// It is a shorthand for New with the WithAuthDomain, WithScheme and WithOAuthApp options.
+func NewClient(domain string, authDomain string, scheme string, clientID string, clientSecret string, redirectURI string) Client {
+	return New(domain,
+		WithAuthDomain(authDomain),
+		WithScheme(scheme),
+		WithOAuthApp(clientID, clientSecret, redirectURI),
+	)
 }
 
-func NewClient(domain string, authDomain string, scheme string, clientID string, clientSecret string, redirectURI string) Client {
-	c := &client{
-		baseURL:      domain,
-		authURL:      authDomain,
-		scheme:       scheme,
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		redirectURI:  redirectURI,
+// apiURL returns the URL of the REST resource at path, relative to the base path of the site.
+// p is escaped, values from outside belong in it through pathSegment.
+func (c *client) apiURL(p string) url.URL {
+	c.routeMu.RLock()
+	defer c.routeMu.RUnlock()
+	rawPath := path.Join("/", c.basePath, p)
+	u := url.URL{
+		Scheme:  c.getScheme(),
+		Host:    c.baseURL,
+		Path:    rawPath,
+		RawPath: rawPath,
+	}
+	if unescaped, err := url.PathUnescape(rawPath); err == nil {
+		u.Path = unescaped
 	}
+	return u
+}
 
-	c.authService = &AuthImpl{c, "", ""}
-	c.issueService = &IssueImpl{c}
+// ErrEmptyID is returned before sending a request whose issue key or ID is empty. The
+// path would otherwise address another resource, e.g. all worklogs instead of one.
+var ErrEmptyID = errors.New("jira: empty key or ID")
+
+// pathSegment escapes s for use as a single segment of a REST path, e.g. an issue key.
+// Neither slashes nor dot segments in s can reach another resource.
+func pathSegment(s string) string {
+	switch s {
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	return url.PathEscape(s)
+}
 
-	return c
+// restURL returns the URL of a resource of the platform REST API, e.g. "search"
+// for rest/api/3/search on Cloud or rest/api/2/search on Data Center.
+func (c *client) restURL(resource string) url.URL {
+	return c.apiURL(path.Join("rest/api", c.apiVersion, resource))
 }
 
 func (c *client) getBaseURL() string {
+	c.routeMu.RLock()
+	defer c.routeMu.RUnlock()
 	return c.baseURL
 }
 
@@ -69,6 +148,17 @@ func (c *client) getRedirectURL() string {
 	return c.redirectURI
 }
 
+// GetDeployment returns the kind of Jira installation the client was configured for.
+func (c *client) GetDeployment() Deployment {
+	return c.deployment
+}
+
+// GetAuthenticator returns the Authenticator that signs the requests of the client.
+// Unless another one was configured, it is the AuthImpl of GetAuthService.
+func (c *client) GetAuthenticator() Authenticator {
+	return c.authenticator
+}
+
 func (c *client) GetAuthService() AuthService {
 	return c.authService
 }
@@ -76,3 +166,7 @@ func (c *client) GetAuthService() AuthService {
 func (c *client) GetIssueService() IssueService {
 	return c.issueService
 }
+
+func (c *client) GetWorklogService() WorklogService {
+	return c.worklogService
+}
@@ -1,9 +1,12 @@
 package jira
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"testing"
+	"time"
 )
 
 var (
@@ -25,21 +28,75 @@ func setup() {
 	testServer = httptest.NewServer(testMux)
 
 	addr := strings.ReplaceAll(testServer.URL, "http://", "")
-	testClient = &client{
-		addr,
-		addr,
-		"http",
-		"test",
-		"test",
-		"test",
-		nil,
-		nil,
-	}
-	testClient.authService = &AuthImpl{testClient, "", ""}
-	testClient.issueService = &IssueImpl{testClient}
+	testClient = newClient(addr,
+		WithScheme("http"),
+		WithAuthDomain(addr),
+		WithOAuthApp("test", "test", "test"),
+	)
 }
 
 // teardown closes the test HTTP server.
 func teardown() {
 	testServer.Close()
 }
+
+type recordingTransport struct {
+	requests int
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNew_Options(t *testing.T) {
+	setup()
+	defer teardown()
+
+	transport := &recordingTransport{}
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := New(addr,
+		WithScheme("http"),
+		WithAuthDomain(addr),
+		WithOAuthApp("test", "test", "test"),
+		WithTransport(transport),
+		WithTimeout(5*time.Second),
+		WithUserAgent("jira-gophers-test"),
+		WithBasePath("/jira/"),
+	)
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "jira-gophers-test" {
+			t.Error("Wanted User-Agent jira-gophers-test but got", r.Header.Get("User-Agent"))
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "token"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	testMux.HandleFunc("/jira/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "jira-gophers-test" {
+			t.Error("Wanted User-Agent jira-gophers-test but got", r.Header.Get("User-Agent"))
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&searchResult{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	_, err := c.GetIssueService().Search("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.requests != 2 {
+		t.Fatal("Wanted 2 requests through the transport but got", transport.requests)
+	}
+
+	if c.(*client).httpClient.Timeout != 5*time.Second {
+		t.Fatal("Wanted timeout 5s but got", c.(*client).httpClient.Timeout)
+	}
+}
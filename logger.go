@@ -0,0 +1,183 @@
+package jira
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Logger receives the log output of the client. keysAndValues alternate between a
+// string key and its value, e.g. logger.Debug("sending request", "method", "GET").
+// Values of keys that hold credentials never reach the Logger, see WithLogger.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// Level is the severity of a log message.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// nopLogger discards everything, it is the default Logger of the client.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// stdLogger adapts a *log.Logger of the standard library.
+type stdLogger struct {
+	logger *log.Logger
+	level  Level
+}
+
+// NewStdLogger returns a Logger that writes messages of at least level to logger as
+// "LEVEL msg key=value ...". A nil logger writes to the standard logger of package log.
+func NewStdLogger(logger *log.Logger, level Level) Logger {
+	return &stdLogger{logger: logger, level: level}
+}
+
+func (l *stdLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.output(LevelDebug, msg, keysAndValues)
+}
+
+func (l *stdLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.output(LevelInfo, msg, keysAndValues)
+}
+
+func (l *stdLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.output(LevelWarn, msg, keysAndValues)
+}
+
+func (l *stdLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.output(LevelError, msg, keysAndValues)
+}
+
+func (l *stdLogger) output(level Level, msg string, keysAndValues []interface{}) {
+	if level < l.level {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(level.String() + " " + msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		b.WriteString(fmt.Sprintf(" %v=", keysAndValues[i]))
+		if i+1 < len(keysAndValues) {
+			b.WriteString(fmt.Sprintf("%q", fmt.Sprint(keysAndValues[i+1])))
+		}
+	}
+	if l.logger == nil {
+		log.Print(b.String())
+		return
+	}
+	l.logger.Print(b.String())
+}
+
+// redactingLogger scrubs credentials from everything it passes on to logger.
+// The client wraps every Logger with it, so custom loggers are safe as well.
+type redactingLogger struct {
+	logger Logger
+}
+
+const redacted = "[REDACTED]"
+
+var (
+	// sensitiveKeys are log keys and JSON/query parameter names whose values are credentials
+	sensitiveKeys = []string{
+		"access_token", "refresh_token", "id_token", "token", "client_secret", "secret",
+		"code", "code_verifier", "authorization", "password", "api_token", "jwt",
+	}
+	headerPattern = regexp.MustCompile(`(?i)\b(bearer|basic|jwt)\s+[A-Za-z0-9\-._~+/=]+`)
+	jsonPattern   = regexp.MustCompile(`(?i)("(?:` + strings.Join(sensitiveKeys, "|") + `)"\s*:\s*")[^"]*(")`)
+	queryPattern  = regexp.MustCompile(`(?i)((?:^|[?&\s;,])(?:` + strings.Join(sensitiveKeys, "|") + `)=)[^&\s"]*`)
+)
+
+func newRedactingLogger(logger Logger) Logger {
+	if logger == nil {
+		return nopLogger{}
+	}
+	if _, ok := logger.(nopLogger); ok {
+		return logger
+	}
+	return &redactingLogger{logger: logger}
+}
+
+func (l *redactingLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.logger.Debug(redactString(msg), redactKeysAndValues(keysAndValues)...)
+}
+
+func (l *redactingLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.logger.Info(redactString(msg), redactKeysAndValues(keysAndValues)...)
+}
+
+func (l *redactingLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.logger.Warn(redactString(msg), redactKeysAndValues(keysAndValues)...)
+}
+
+func (l *redactingLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.logger.Error(redactString(msg), redactKeysAndValues(keysAndValues)...)
+}
+
+func redactKeysAndValues(keysAndValues []interface{}) []interface{} {
+	out := make([]interface{}, len(keysAndValues))
+	for i := 0; i < len(keysAndValues); i += 2 {
+		out[i] = keysAndValues[i]
+		if i+1 >= len(keysAndValues) {
+			break
+		}
+		if isSensitiveKey(fmt.Sprint(keysAndValues[i])) {
+			out[i+1] = redacted
+			continue
+		}
+		switch v := keysAndValues[i+1].(type) {
+		case string:
+			out[i+1] = redactString(v)
+		case error, fmt.Stringer:
+			out[i+1] = redactString(fmt.Sprint(v))
+		default:
+			out[i+1] = v
+		}
+	}
+	return out
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if key == k || strings.HasSuffix(key, "_"+k) {
+			return true
+		}
+	}
+	return strings.HasSuffix(key, "token") || strings.HasSuffix(key, "secret")
+}
+
+// redactString masks credentials in authorization headers, JSON bodies and query strings.
+func redactString(s string) string {
+	s = headerPattern.ReplaceAllString(s, "$1 "+redacted)
+	s = jsonPattern.ReplaceAllString(s, "${1}"+redacted+"${2}")
+	s = queryPattern.ReplaceAllString(s, "${1}"+redacted)
+	return s
+}
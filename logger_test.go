@@ -0,0 +1,78 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStdLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), LevelWarn)
+
+	logger.Info("hidden")
+	logger.Warn("shown", "status", 429)
+
+	if buf.String() != "WARN shown status=\"429\"\n" {
+		t.Fatal("Wanted only the warning but got", buf.String())
+	}
+}
+
+func TestRedactingLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRedactingLogger(NewStdLogger(log.New(&buf, "", 0), LevelDebug))
+
+	logger.Debug("exchanging code",
+		"refresh_token", "my-refresh-token",
+		"client_secret", "my-client-secret",
+		"header", "Bearer my-access-token",
+		"body", `{"access_token":"my-access-token","expires_in":3600}`,
+		"url", "https://example.com/callback?state=abc&code=my-code",
+		"error", errors.New("refresh failed for token=my-refresh-token"),
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"my-refresh-token", "my-client-secret", "my-access-token", "my-code"} {
+		if strings.Contains(out, secret) {
+			t.Fatal("Wanted", secret, "to be redacted but got", out)
+		}
+	}
+
+	if !strings.Contains(out, "state=abc") || !strings.Contains(out, "expires_in") {
+		t.Fatal("Wanted non secret values to be kept but got", out)
+	}
+}
+
+func TestClient_LoggerNeverSeesTokens(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var buf bytes.Buffer
+	testClient.logger = newRedactingLogger(NewStdLogger(log.New(&buf, "", 0), LevelDebug))
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		err := json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "refresh_token": "leaked-refresh-token"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	testClient.GetAuthService().SetRefreshToken("leaked-refresh-token")
+	_, err := testClient.GetAuthService().GetAccessTokenFromRefreshToken()
+	if err == nil {
+		t.Fatal("Wanted an error from the auth server")
+	}
+
+	if buf.Len() == 0 {
+		t.Fatal("Wanted the error to be logged")
+	}
+
+	if strings.Contains(buf.String(), "leaked-refresh-token") {
+		t.Fatal("Wanted the refresh token to be redacted but got", buf.String())
+	}
+}
@@ -0,0 +1,107 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// OAuthRevokeRequest is the body of a token revocation at the auth server.
+type OAuthRevokeRequest struct {
+	Token         string `json:"token"`
+	TokenTypeHint string `json:"token_type_hint,omitempty"`
+	ClientID      string `json:"client_id"`
+	ClientSecret  string `json:"client_secret"`
+}
+
+// LogoutError reports the steps of a logout that failed. The tokens are wiped from
+// memory even then, so the client is logged out locally in any case.
+type LogoutError struct {
+	// LoadErr is why the tokens could not be read from the TokenStore. They were neither
+	// revoked nor deleted, the stored copy is kept so that the logout can be retried.
+	LoadErr error
+	// RevokeErr is why the auth server did not revoke the token, the grant may still be
+	// valid and has to be removed by the user in their Atlassian account settings.
+	RevokeErr error
+	// DeleteErr is why the tokens could not be deleted from the TokenStore.
+	DeleteErr error
+}
+
+func (e *LogoutError) Error() string {
+	var parts []string
+	if e.LoadErr != nil {
+		parts = append(parts, "loading the stored token failed: "+e.LoadErr.Error())
+	}
+	if e.RevokeErr != nil {
+		parts = append(parts, "revoking the token failed: "+e.RevokeErr.Error())
+	}
+	if e.DeleteErr != nil {
+		parts = append(parts, "deleting the stored token failed: "+e.DeleteErr.Error())
+	}
+	return "jira: logout incomplete, " + strings.Join(parts, ", ")
+}
+
+// Is reports whether one of the failed steps matches target.
+func (e *LogoutError) Is(target error) bool {
+	return (e.LoadErr != nil && errors.Is(e.LoadErr, target)) ||
+		(e.RevokeErr != nil && errors.Is(e.RevokeErr, target)) ||
+		(e.DeleteErr != nil && errors.Is(e.DeleteErr, target))
+}
+
+// As finds the first error of the failed steps that matches target.
+func (e *LogoutError) As(target interface{}) bool {
+	return (e.LoadErr != nil && errors.As(e.LoadErr, target)) ||
+		(e.RevokeErr != nil && errors.As(e.RevokeErr, target)) ||
+		(e.DeleteErr != nil && errors.As(e.DeleteErr, target))
+}
+
+func (a *AuthImpl) Logout() error {
+	return a.LogoutContext(context.Background())
+}
+
+// LogoutContext revokes the refresh token at the auth server, which ends the grant of
+// the app, forgets the tokens and deletes them from the TokenStore, if any. Without a
+// refresh token the access token is revoked instead. Failed steps are reported in a
+// LogoutError, the others still run, except that tokens which could not be loaded from
+// the store are not deleted from it.
+func (a *AuthImpl) LogoutContext(ctx context.Context) error {
+	logoutErr := &LogoutError{}
+	a.mu.Lock()
+	logoutErr.LoadErr = a.loadLocked(ctx)
+	token, hint := a.refreshToken, "refresh_token"
+	if token == "" {
+		token, hint = a.accessToken, "access_token"
+	}
+	a.accessToken = ""
+	a.refreshToken = ""
+	a.expiresAt = time.Time{}
+	a.scope = ""
+	// drop a refresh in flight so that it cannot bring the tokens back
+	a.refreshing = nil
+	if logoutErr.LoadErr == nil {
+		// the stored copy is about to be deleted, there is nothing left to load
+		a.loaded = true
+	}
+	a.mu.Unlock()
+
+	if token != "" {
+		payload := OAuthRevokeRequest{
+			Token:         token,
+			TokenTypeHint: hint,
+			ClientID:      a.client.getClientID(),
+			ClientSecret:  a.client.getClientSecret(),
+		}
+		logoutErr.RevokeErr = a.postAuth(ctx, "Logout", "oauth/revoke", &payload, nil)
+	}
+	// a token that could not be read was not revoked, deleting it would lose the grant
+	if a.store != nil && logoutErr.LoadErr == nil {
+		// like saving, deleting must not be skipped because the request was cancelled
+		logoutErr.DeleteErr = a.store.Delete(context.Background())
+	}
+
+	if logoutErr.LoadErr != nil || logoutErr.RevokeErr != nil || logoutErr.DeleteErr != nil {
+		return logoutErr
+	}
+	return nil
+}
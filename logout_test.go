@@ -0,0 +1,129 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogout(t *testing.T) {
+	setup()
+	defer teardown()
+
+	revoked := ""
+	testMux.HandleFunc("/oauth/revoke", func(w http.ResponseWriter, r *http.Request) {
+		var payload OAuthRevokeRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.TokenTypeHint != "refresh_token" || payload.ClientID != "test" {
+			t.Error("Wanted a refresh token revocation of the app but got", payload)
+		}
+		revoked = payload.Token
+		w.WriteHeader(200)
+	})
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	c := New(addr, WithScheme("http"), WithAuthDomain(addr), WithOAuthApp("test", "test", "test"), WithTokenStore(store))
+	auth := c.GetAuthService()
+	auth.SetRefreshToken("refresh")
+	auth.SetAccessToken("access")
+
+	if err := auth.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	if revoked != "refresh" {
+		t.Fatal("Wanted the refresh token to be revoked but got", revoked)
+	}
+	if auth.GetAccessToken() != "" || auth.GetRefreshToken() != "" {
+		t.Fatal("Wanted the tokens to be wiped but got", auth.GetAccessToken(), auth.GetRefreshToken())
+	}
+	if _, err := store.Load(context.Background()); !errors.Is(err, ErrNoToken) {
+		t.Fatal("Wanted the stored token to be deleted but got", err)
+	}
+}
+
+func TestLogout_RevokeFails(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/revoke", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte(`{"error":"invalid_request","error_description":"unknown token"}`))
+	})
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	store := NewMemoryTokenStore()
+	c := New(addr, WithScheme("http"), WithAuthDomain(addr), WithOAuthApp("test", "test", "test"), WithTokenStore(store))
+	c.GetAuthService().SetRefreshToken("refresh")
+
+	err := c.GetAuthService().Logout()
+	var logoutErr *LogoutError
+	if !errors.As(err, &logoutErr) || logoutErr.DeleteErr != nil {
+		t.Fatal("Wanted a LogoutError for the revocation only but got", err)
+	}
+	if !errors.Is(err, ErrBadRequest) {
+		t.Fatal("Wanted the LogoutError to match ErrBadRequest but got", err)
+	}
+	var jiraErr *Error
+	if !errors.As(err, &jiraErr) || jiraErr.StatusCode != 400 {
+		t.Fatal("Wanted the Error of the auth server but got", err)
+	}
+
+	if c.GetAuthService().GetRefreshToken() != "" {
+		t.Fatal("Wanted the tokens to be wiped anyway but got", c.GetAuthService().GetRefreshToken())
+	}
+	if _, err := store.Load(context.Background()); !errors.Is(err, ErrNoToken) {
+		t.Fatal("Wanted the stored token to be deleted anyway but got", err)
+	}
+}
+
+// failingTokenStore cannot read its token, e.g. because the key changed.
+type failingTokenStore struct {
+	deleted bool
+}
+
+func (s *failingTokenStore) Load(ctx context.Context) (*Token, error) {
+	return nil, errors.New("decrypting token failed")
+}
+
+func (s *failingTokenStore) Save(ctx context.Context, token *Token) error {
+	return nil
+}
+
+func (s *failingTokenStore) Delete(ctx context.Context) error {
+	s.deleted = true
+	return nil
+}
+
+func TestLogout_LoadFails(t *testing.T) {
+	setup()
+	defer teardown()
+
+	revokes := 0
+	testMux.HandleFunc("/oauth/revoke", func(w http.ResponseWriter, r *http.Request) {
+		revokes++
+		w.WriteHeader(200)
+	})
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	store := &failingTokenStore{}
+	c := New(addr, WithScheme("http"), WithAuthDomain(addr), WithOAuthApp("test", "test", "test"), WithTokenStore(store))
+
+	err := c.GetAuthService().Logout()
+	var logoutErr *LogoutError
+	if !errors.As(err, &logoutErr) || logoutErr.LoadErr == nil {
+		t.Fatal("Wanted a LogoutError for the failed load but got", err)
+	}
+	if revokes != 0 {
+		t.Fatal("Wanted no revocation without a token but got", revokes)
+	}
+	if store.deleted {
+		t.Fatal("Wanted the unreadable token to be kept in the store")
+	}
+}
@@ -0,0 +1,119 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// defaultLoginTimeout is how long LoginWithLoopback waits for the user by default.
+const defaultLoginTimeout = 5 * time.Minute
+
+// LoginOptions configures LoginWithLoopback.
+type LoginOptions struct {
+	// Scopes are requested from the user, e.g. "read:jira-work" and "offline_access"
+	Scopes []string
+	// Timeout is how long to wait for the callback. Default: 5 minutes.
+	Timeout time.Duration
+	// OpenBrowser opens the authorize URL, e.g. with xdg-open or open.
+	// If it is nil or fails, the user has to open the printed URL.
+	OpenBrowser func(url string) error
+	// Output receives the message with the authorize URL. Default: os.Stderr.
+	Output io.Writer
+}
+
+// LoginWithLoopback logs in interactively from a command line tool. It listens on the
+// redirect URI of the client, which has to be a loopback address with a port such as
+// http://127.0.0.1:8080/callback, prints and opens the authorize URL, waits for the
+// callback, checks its state and exchanges the code. The returned Client is c, ready
+// to send requests with the new tokens.
+func LoginWithLoopback(ctx context.Context, c Client, options LoginOptions) (Client, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = defaultLoginTimeout
+	}
+	if options.Output == nil {
+		options.Output = os.Stderr
+	}
+
+	auth := c.GetAuthService()
+	authRequest, err := auth.NewAuthorizationRequest(options.Scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	redirect, err := url.Parse(authRequest.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+	if !isLoopback(redirect.Hostname()) || redirect.Port() == "" {
+		return nil, errors.New("jira: the redirect URI " + authRequest.RedirectURI + " is not a loopback address with a port")
+	}
+
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	mux := http.NewServeMux()
+	path := redirect.Path
+	if path == "" {
+		path = "/"
+	}
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		// not our callback, maybe a stale browser tab or another page aborting the login,
+		// keep waiting for the real one, errors included
+		if authRequest.ValidateState(query.Get("state")) != nil {
+			http.Error(w, "Unknown login request.", http.StatusBadRequest)
+			return
+		}
+		_, err := auth.GetAccessTokenFromCallbackContext(ctx, authRequest, query)
+		if err != nil {
+			http.Error(w, "Login to Jira failed, please check the terminal.", http.StatusInternalServerError)
+		} else {
+			fmt.Fprintln(w, "Logged in to Jira. You can close this window now.")
+		}
+		select {
+		case done <- err:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintf(options.Output, "Open the following URL in your browser to log in to Jira:\n\n%s\n\n", authRequest.URL)
+	if options.OpenBrowser != nil {
+		if err := options.OpenBrowser(authRequest.URL); err != nil {
+			fmt.Fprintf(options.Output, "Could not open the browser: %v\n", err)
+		}
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,128 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginWithLoopback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// find a free port for the redirect URI
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirectURI := "http://" + listener.Addr().String() + "/callback"
+	listener.Close()
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := New(addr,
+		WithScheme("http"),
+		WithAuthDomain(addr),
+		WithOAuthApp("test", "test", redirectURI),
+	)
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		var payload OAuthRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Code != "code" || payload.CodeVerifier == "" || payload.RedirectURI != redirectURI {
+			t.Error("Wanted code, code verifier and redirect URI but got", payload)
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "access", RefreshToken: "refresh"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	var output bytes.Buffer
+	browser := func(authorizeURL string) error {
+		u, err := url.Parse(authorizeURL)
+		if err != nil {
+			return err
+		}
+		go func() {
+			// a stray callback with the wrong state must not end the login
+			resp, err := http.Get(redirectURI + "?code=forged&state=forged")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != 400 {
+				t.Error("Wanted 400 for a forged callback but got", resp.StatusCode)
+			}
+
+			// neither must an error callback without our state
+			resp, err = http.Get(redirectURI + "?error=access_denied")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != 400 {
+				t.Error("Wanted 400 for a forged error callback but got", resp.StatusCode)
+			}
+
+			resp, err = http.Get(redirectURI + "?code=code&state=" + url.QueryEscape(u.Query().Get("state")))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if !strings.Contains(string(body), "Logged in") {
+				t.Error("Wanted the success page but got", string(body))
+			}
+		}()
+		return nil
+	}
+
+	logged, err := LoginWithLoopback(context.Background(), c, LoginOptions{
+		Scopes:      []string{"read:jira-work", "offline_access"},
+		Timeout:     5 * time.Second,
+		OpenBrowser: browser,
+		Output:      &output,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if logged.GetAuthService().GetAccessToken() != "access" || logged.GetAuthService().GetRefreshToken() != "refresh" {
+		t.Fatal("Wanted the client to hold the new tokens")
+	}
+
+	if !strings.Contains(output.String(), "/authorize?") {
+		t.Fatal("Wanted the authorize URL to be printed but got", output.String())
+	}
+}
+
+func TestLoginWithLoopback_Timeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirectURI := "http://" + listener.Addr().String() + "/callback"
+	listener.Close()
+
+	c := New("example.atlassian.net", WithOAuthApp("test", "test", redirectURI))
+	_, err = LoginWithLoopback(context.Background(), c, LoginOptions{
+		Timeout: 50 * time.Millisecond,
+		Output:  ioutil.Discard,
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatal("Wanted context.DeadlineExceeded but got", err)
+	}
+}
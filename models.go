@@ -1,6 +1,7 @@
 package jira
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/trivago/tgo/tcontainer"
@@ -24,6 +25,18 @@ type SearchOptions struct {
 	ValidateQuery string `url:"validateQuery,omitempty"`
 }
 
+// GetQueryOptions specifies the optional parameters of IssueService.Get.
+type GetQueryOptions struct {
+	// Fields: The fields to return, e.g. "summary" or "-description". Default: all navigable fields.
+	Fields []string
+	// Expand: Comma separated sections to include, e.g. "changelog,renderedFields,transitions,names"
+	Expand string
+	// Properties: The issue properties to return in Issue.Properties, "*all" for all of them
+	Properties []string
+	// UpdateHistory: Adds the issue to the recently viewed issues of the user
+	UpdateHistory bool
+}
+
 // ChangelogItems reflects one single changelog item of a history item
 type ChangelogItems struct {
 	Field      string      `json:"field" structs:"field"`
@@ -118,6 +131,25 @@ type IssueFields struct {
 	Unknowns                      tcontainer.MarshalMap
 }
 
+// UnmarshalJSON accepts the description as plain string, as the REST API v2 sends it, or
+// as Atlassian Document Format, as v3 does, and keeps its text in Description.
+func (i *IssueFields) UnmarshalJSON(b []byte) error {
+	type fields IssueFields
+	aux := struct {
+		*fields
+		Description json.RawMessage `json:"description,omitempty"`
+	}{fields: (*fields)(i)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	description, err := commentText(aux.Description)
+	if err != nil {
+		return err
+	}
+	i.Description = description
+	return nil
+}
+
 // Parent represents the parent of a Jira issue, to be used with subtask issue types.
 type Parent struct {
 	ID  string `json:"id,omitempty" structs:"id"`
@@ -197,6 +229,7 @@ type Watches struct {
 type Watcher struct {
 	Self        string `json:"self,omitempty" structs:"self,omitempty"`
 	Name        string `json:"name,omitempty" structs:"name,omitempty"`
+	Key         string `json:"key,omitempty" structs:"key,omitempty"`
 	AccountID   string `json:"accountId,omitempty" structs:"accountId,omitempty"`
 	DisplayName string `json:"displayName,omitempty" structs:"displayName,omitempty"`
 	Active      bool   `json:"active,omitempty" structs:"active,omitempty"`
@@ -279,18 +312,38 @@ type TimeTracking struct {
 
 // WorklogRecord represents one entry of a Worklog
 type WorklogRecord struct {
-	Self             string           `json:"self,omitempty" structs:"self,omitempty"`
-	Author           *User            `json:"author,omitempty" structs:"author,omitempty"`
-	UpdateAuthor     *User            `json:"updateAuthor,omitempty" structs:"updateAuthor,omitempty"`
-	Comment          string           `json:"comment,omitempty" structs:"comment,omitempty"`
-	Created          *Time            `json:"created,omitempty" structs:"created,omitempty"`
-	Updated          *Time            `json:"updated,omitempty" structs:"updated,omitempty"`
-	Started          *Time            `json:"started,omitempty" structs:"started,omitempty"`
-	TimeSpent        string           `json:"timeSpent,omitempty" structs:"timeSpent,omitempty"`
-	TimeSpentSeconds int              `json:"timeSpentSeconds,omitempty" structs:"timeSpentSeconds,omitempty"`
-	ID               string           `json:"id,omitempty" structs:"id,omitempty"`
-	IssueID          string           `json:"issueId,omitempty" structs:"issueId,omitempty"`
-	Properties       []EntityProperty `json:"properties,omitempty"`
+	Self             string             `json:"self,omitempty" structs:"self,omitempty"`
+	Author           *User              `json:"author,omitempty" structs:"author,omitempty"`
+	UpdateAuthor     *User              `json:"updateAuthor,omitempty" structs:"updateAuthor,omitempty"`
+	Comment          string             `json:"comment,omitempty" structs:"comment,omitempty"`
+	Visibility       *CommentVisibility `json:"visibility,omitempty" structs:"visibility,omitempty"`
+	Created          *Time              `json:"created,omitempty" structs:"created,omitempty"`
+	Updated          *Time              `json:"updated,omitempty" structs:"updated,omitempty"`
+	Started          *Time              `json:"started,omitempty" structs:"started,omitempty"`
+	TimeSpent        string             `json:"timeSpent,omitempty" structs:"timeSpent,omitempty"`
+	TimeSpentSeconds int                `json:"timeSpentSeconds,omitempty" structs:"timeSpentSeconds,omitempty"`
+	ID               string             `json:"id,omitempty" structs:"id,omitempty"`
+	IssueID          string             `json:"issueId,omitempty" structs:"issueId,omitempty"`
+	Properties       []EntityProperty   `json:"properties,omitempty"`
+}
+
+// UnmarshalJSON accepts the comment as plain string, as the REST API v2 sends it, or as
+// Atlassian Document Format, as v3 does, and keeps its text in Comment.
+func (w *WorklogRecord) UnmarshalJSON(b []byte) error {
+	type record WorklogRecord
+	aux := struct {
+		*record
+		Comment json.RawMessage `json:"comment,omitempty"`
+	}{record: (*record)(w)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	comment, err := commentText(aux.Comment)
+	if err != nil {
+		return err
+	}
+	w.Comment = comment
+	return nil
 }
 
 type EntityProperty struct {
@@ -414,15 +467,16 @@ type TransitionField struct {
 
 // Issue represents a Jira issue.
 type Issue struct {
-	Expand         string               `json:"expand,omitempty" structs:"expand,omitempty"`
-	ID             string               `json:"id,omitempty" structs:"id,omitempty"`
-	Self           string               `json:"self,omitempty" structs:"self,omitempty"`
-	Key            string               `json:"key,omitempty" structs:"key,omitempty"`
-	Fields         *IssueFields         `json:"fields,omitempty" structs:"fields,omitempty"`
-	RenderedFields *IssueRenderedFields `json:"renderedFields,omitempty" structs:"renderedFields,omitempty"`
-	Changelog      *Changelog           `json:"changelog,omitempty" structs:"changelog,omitempty"`
-	Transitions    []Transition         `json:"transitions,omitempty" structs:"transitions,omitempty"`
-	Names          map[string]string    `json:"names,omitempty" structs:"names,omitempty"`
+	Expand         string                 `json:"expand,omitempty" structs:"expand,omitempty"`
+	ID             string                 `json:"id,omitempty" structs:"id,omitempty"`
+	Self           string                 `json:"self,omitempty" structs:"self,omitempty"`
+	Key            string                 `json:"key,omitempty" structs:"key,omitempty"`
+	Fields         *IssueFields           `json:"fields,omitempty" structs:"fields,omitempty"`
+	RenderedFields *IssueRenderedFields   `json:"renderedFields,omitempty" structs:"renderedFields,omitempty"`
+	Changelog      *Changelog             `json:"changelog,omitempty" structs:"changelog,omitempty"`
+	Transitions    []Transition           `json:"transitions,omitempty" structs:"transitions,omitempty"`
+	Names          map[string]string      `json:"names,omitempty" structs:"names,omitempty"`
+	Properties     map[string]interface{} `json:"properties,omitempty" structs:"properties,omitempty"`
 }
 
 // IssueRenderedFields represents rendered fields of a Jira issue.
@@ -473,6 +527,25 @@ type Comment struct {
 	Visibility   CommentVisibility `json:"visibility,omitempty" structs:"visibility,omitempty"`
 }
 
+// UnmarshalJSON accepts the body as plain string or as Atlassian Document Format,
+// see IssueFields.UnmarshalJSON.
+func (c *Comment) UnmarshalJSON(b []byte) error {
+	type comment Comment
+	aux := struct {
+		*comment
+		Body json.RawMessage `json:"body,omitempty"`
+	}{comment: (*comment)(c)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	body, err := commentText(aux.Body)
+	if err != nil {
+		return err
+	}
+	c.Body = body
+	return nil
+}
+
 // CommentVisibility represents he visibility of a comment.
 // E.g. Type could be "role" and Value "Administrators"
 type CommentVisibility struct {
@@ -495,10 +568,27 @@ type Sprint struct {
 // searchResult is only a small wrapper around the Search (with JQL) method
 // to be able to parse the results
 type searchResult struct {
-	Issues     []Issue `json:"issues" structs:"issues"`
-	StartAt    int     `json:"startAt" structs:"startAt"`
-	MaxResults int     `json:"maxResults" structs:"maxResults"`
-	Total      int     `json:"total" structs:"total"`
+	Issues          []Issue                `json:"issues" structs:"issues"`
+	StartAt         int                    `json:"startAt" structs:"startAt"`
+	MaxResults      int                    `json:"maxResults" structs:"maxResults"`
+	Total           int                    `json:"total" structs:"total"`
+	Names           map[string]string      `json:"names,omitempty" structs:"names,omitempty"`
+	Schema          map[string]FieldSchema `json:"schema,omitempty" structs:"schema,omitempty"`
+	WarningMessages []string               `json:"warningMessages,omitempty" structs:"warningMessages,omitempty"`
+}
+
+// SearchResult represents one page of a Search (with JQL) call together with its metadata.
+// Names and Schema are only filled when the search expands "names" and "schema".
+// WarningMessages holds the warnings Jira reports for soft JQL errors, e.g. unknown values.
+type SearchResult searchResult
+
+// FieldSchema represents the schema of an issue field as returned with expand=schema
+type FieldSchema struct {
+	Type     string `json:"type,omitempty" structs:"type,omitempty"`
+	Items    string `json:"items,omitempty" structs:"items,omitempty"`
+	System   string `json:"system,omitempty" structs:"system,omitempty"`
+	Custom   string `json:"custom,omitempty" structs:"custom,omitempty"`
+	CustomID int    `json:"customId,omitempty" structs:"customId,omitempty"`
 }
 
 // User represents a Jira user.
@@ -518,6 +608,18 @@ type User struct {
 	ApplicationKeys []string   `json:"applicationKeys,omitempty" structs:"applicationKeys,omitempty"`
 }
 
+// Identifier returns what identifies the user in requests: the account ID on Jira Cloud,
+// the username on Jira Server and Data Center, which do not know account IDs.
+func (u *User) Identifier(deployment Deployment) string {
+	if deployment == DeploymentServer {
+		if u.Name != "" {
+			return u.Name
+		}
+		return u.Key
+	}
+	return u.AccountID
+}
+
 // AvatarUrls represents different dimensions of avatars / images
 type AvatarUrls struct {
 	Four8X48  string `json:"48x48,omitempty" structs:"48x48,omitempty"`
@@ -526,11 +628,13 @@ type AvatarUrls struct {
 	Three2X32 string `json:"32x32,omitempty" structs:"32x32,omitempty"`
 }
 
-type Error struct {
-	StatusCode int
-	Body       map[string]interface{}
-}
-
+// WorkLog is the body of a new or updated worklog. Either TimeSpent, e.g. "3h 20m", or
+// TimeSpentSeconds is required when adding one. Comment is sent as plain string to the
+// REST API v2 and as Atlassian Document Format to v3.
 type WorkLog struct {
-	TimeSpent string `json:"timeSpent"`
+	TimeSpent        string             `json:"timeSpent,omitempty"`
+	TimeSpentSeconds int                `json:"timeSpentSeconds,omitempty"`
+	Started          *Time              `json:"started,omitempty"`
+	Comment          string             `json:"-"`
+	Visibility       *CommentVisibility `json:"visibility,omitempty"`
 }
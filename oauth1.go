@@ -0,0 +1,273 @@
+package jira
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth1 authenticates with OAuth 1.0a and RSA-SHA1 signatures, the only way into older
+// Jira Server installations that integrate through application links. Configure the
+// application link with the consumer key and the public key of privateKey, then run the
+// three legged flow: GetRequestToken, send the user to AuthorizeURL and exchange the
+// verifier with GetAccessToken. Pass the OAuth1 to WithAuthenticator to sign requests.
+// It is safe for concurrent use.
+type OAuth1 struct {
+	baseURL     string
+	consumerKey string
+	privateKey  *rsa.PrivateKey
+	callbackURL string
+	httpClient  *http.Client
+	now         func() time.Time
+
+	mu          sync.Mutex
+	token       string
+	tokenSecret string
+}
+
+// NewOAuth1 returns an OAuth1 for the Jira Server at baseURL, e.g.
+// "https://jira.example.com/jira". callbackURL receives the verifier after the user
+// authorized the request token, "oob" lets Jira show the verifier instead.
+func NewOAuth1(baseURL string, consumerKey string, privateKey *rsa.PrivateKey, callbackURL string) *OAuth1 {
+	return &OAuth1{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		consumerKey: consumerKey,
+		privateKey:  privateKey,
+		callbackURL: callbackURL,
+		httpClient:  &http.Client{},
+		now:         time.Now,
+	}
+}
+
+// ParseRSAPrivateKey parses a PEM encoded PKCS #1 or PKCS #8 RSA private key.
+func ParseRSAPrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("jira: no PEM block in the private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("jira: the private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// SetHTTPClient sets the http.Client used for the token requests.
+func (o *OAuth1) SetHTTPClient(httpClient *http.Client) {
+	o.httpClient = httpClient
+}
+
+// SetAccessToken sets an access token obtained earlier, e.g. loaded from disk.
+func (o *OAuth1) SetAccessToken(token string, tokenSecret string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.token = token
+	o.tokenSecret = tokenSecret
+}
+
+// GetAccessToken returns the access token and its secret set by GetAccessTokenFromVerifier
+// or SetAccessToken.
+func (o *OAuth1) GetAccessToken() (string, string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.token, o.tokenSecret
+}
+
+func (o *OAuth1) GetRequestToken() (string, string, error) {
+	return o.GetRequestTokenContext(context.Background())
+}
+
+// GetRequestTokenContext fetches a temporary request token and its secret.
+func (o *OAuth1) GetRequestTokenContext(ctx context.Context) (string, string, error) {
+	values, err := o.postToken(ctx, "plugins/servlet/oauth/request-token", map[string]string{
+		"oauth_callback": o.callbackURL,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return values.Get("oauth_token"), values.Get("oauth_token_secret"), nil
+}
+
+// AuthorizeURL returns the URL where the user authorizes requestToken.
+func (o *OAuth1) AuthorizeURL(requestToken string) string {
+	return o.baseURL + "/plugins/servlet/oauth/authorize?oauth_token=" + url.QueryEscape(requestToken)
+}
+
+func (o *OAuth1) GetAccessTokenFromVerifier(requestToken string, verifier string) (string, string, error) {
+	return o.GetAccessTokenFromVerifierContext(context.Background(), requestToken, verifier)
+}
+
+// GetAccessTokenFromVerifierContext exchanges the authorized request token and the
+// verifier for an access token, which is kept to sign the following requests.
+func (o *OAuth1) GetAccessTokenFromVerifierContext(ctx context.Context, requestToken string, verifier string) (string, string, error) {
+	values, err := o.postToken(ctx, "plugins/servlet/oauth/access-token", map[string]string{
+		"oauth_token":    requestToken,
+		"oauth_verifier": verifier,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	token, secret := values.Get("oauth_token"), values.Get("oauth_token_secret")
+	o.SetAccessToken(token, secret)
+	return token, secret, nil
+}
+
+// Authenticate signs req with the access token.
+func (o *OAuth1) Authenticate(req *http.Request) error {
+	token, _ := o.GetAccessToken()
+	oauthParams := map[string]string{}
+	if token != "" {
+		oauthParams["oauth_token"] = token
+	}
+	return o.sign(req, oauthParams)
+}
+
+// postToken sends a signed POST to one of the token endpoints and parses the form encoded answer.
+func (o *OAuth1) postToken(ctx context.Context, endpoint string, oauthParams map[string]string) (url.Values, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/"+endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.sign(req, oauthParams); err != nil {
+		return nil, err
+	}
+
+	res, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != 200 {
+		return nil, newError(res, body)
+	}
+	return url.ParseQuery(string(body))
+}
+
+// sign adds the RSA-SHA1 signature of req as OAuth Authorization header.
+func (o *OAuth1) sign(req *http.Request, oauthParams map[string]string) error {
+	nonce, err := randomString()
+	if err != nil {
+		return err
+	}
+	oauthParams["oauth_consumer_key"] = o.consumerKey
+	oauthParams["oauth_nonce"] = nonce
+	oauthParams["oauth_signature_method"] = "RSA-SHA1"
+	oauthParams["oauth_timestamp"] = strconv.FormatInt(o.now().Unix(), 10)
+	oauthParams["oauth_version"] = "1.0"
+
+	params := req.URL.Query()
+	if req.Header.Get("Content-Type") == "application/x-www-form-urlencoded" && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return err
+		}
+		for k, vs := range form {
+			params[k] = append(params[k], vs...)
+		}
+	}
+	for k, v := range oauthParams {
+		params.Set(k, v)
+	}
+
+	hash := sha1.Sum([]byte(oauth1BaseString(req.Method, req.URL, params)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, o.privateKey, crypto.SHA1, hash[:])
+	if err != nil {
+		return err
+	}
+	oauthParams["oauth_signature"] = base64.StdEncoding.EncodeToString(signature)
+
+	keys := make([]string, 0, len(oauthParams))
+	for k := range oauthParams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = percentEncode(k) + `="` + percentEncode(oauthParams[k]) + `"`
+	}
+	req.Header.Set("Authorization", "OAuth "+strings.Join(parts, ", "))
+	return nil
+}
+
+// oauth1BaseString builds the signature base string of RFC 5849, section 3.4.1.
+func oauth1BaseString(method string, u *url.URL, params url.Values) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+	if (scheme == "http" && strings.HasSuffix(host, ":80")) || (scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+	baseURI := scheme + "://" + host + u.EscapedPath()
+
+	type pair struct{ k, v string }
+	pairs := make([]pair, 0, len(params))
+	for k, vs := range params {
+		for _, v := range vs {
+			pairs = append(pairs, pair{percentEncode(k), percentEncode(v)})
+		}
+	}
+	// the encoded parameters are sorted by name first and value second
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].k != pairs[j].k {
+			return pairs[i].k < pairs[j].k
+		}
+		return pairs[i].v < pairs[j].v
+	})
+	normalized := make([]string, len(pairs))
+	for i, p := range pairs {
+		normalized[i] = p.k + "=" + p.v
+	}
+
+	return strings.ToUpper(method) + "&" + percentEncode(baseURI) + "&" + percentEncode(strings.Join(normalized, "&"))
+}
+
+// percentEncode encodes s as RFC 3986 requires, leaving only unreserved characters as they are.
+func percentEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&15])
+	}
+	return b.String()
+}
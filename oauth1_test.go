@@ -0,0 +1,141 @@
+package jira
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// verifyOAuth1 checks the RSA-SHA1 signature of r and returns the OAuth parameters.
+func verifyOAuth1(t *testing.T, r *http.Request, key *rsa.PublicKey) map[string]string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "OAuth ") {
+		t.Fatal("Wanted an OAuth Authorization header but got", header)
+	}
+	oauthParams := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(header, "OAuth "), ", ") {
+		kv := strings.SplitN(part, "=", 2)
+		v, err := url.PathUnescape(strings.Trim(kv[1], `"`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oauthParams[kv[0]] = v
+	}
+
+	params := r.URL.Query()
+	for k, v := range oauthParams {
+		if k != "oauth_signature" {
+			params.Set(k, v)
+		}
+	}
+	u := &url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+	hash := sha1.Sum([]byte(oauth1BaseString(r.Method, u, params)))
+	signature, err := base64.StdEncoding.DecodeString(oauthParams["oauth_signature"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA1, hash[:], signature); err != nil {
+		t.Fatal("Wanted a valid signature but got", err)
+	}
+	if oauthParams["oauth_consumer_key"] != "jira-gophers" || oauthParams["oauth_signature_method"] != "RSA-SHA1" {
+		t.Fatal("Wanted the consumer key and RSA-SHA1 but got", oauthParams)
+	}
+	return oauthParams
+}
+
+func TestOAuth1(t *testing.T) {
+	setup()
+	defer teardown()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testMux.HandleFunc("/jira/plugins/servlet/oauth/request-token", func(w http.ResponseWriter, r *http.Request) {
+		params := verifyOAuth1(t, r, &key.PublicKey)
+		if params["oauth_callback"] != "oob" {
+			t.Error("Wanted the callback oob but got", params["oauth_callback"])
+		}
+		_, _ = w.Write([]byte("oauth_token=request-token&oauth_token_secret=request-secret"))
+	})
+	testMux.HandleFunc("/jira/plugins/servlet/oauth/access-token", func(w http.ResponseWriter, r *http.Request) {
+		params := verifyOAuth1(t, r, &key.PublicKey)
+		if params["oauth_token"] != "request-token" || params["oauth_verifier"] != "verifier" {
+			t.Error("Wanted the request token and verifier but got", params)
+		}
+		_, _ = w.Write([]byte("oauth_token=access-token&oauth_token_secret=access-secret"))
+	})
+	testMux.HandleFunc("/jira/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		params := verifyOAuth1(t, r, &key.PublicKey)
+		if params["oauth_token"] != "access-token" {
+			t.Error("Wanted the access token but got", params["oauth_token"])
+		}
+		if r.URL.Query().Get("jql") != "project = TEST" {
+			t.Error("Wanted the jql parameter but got", r.URL.Query().Get("jql"))
+		}
+		_, _ = w.Write([]byte(`{"issues":[{"key":"TEST-1"}]}`))
+	})
+
+	oauth := NewOAuth1(testServer.URL+"/jira/", "jira-gophers", key, "oob")
+	token, secret, err := oauth.GetRequestToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "request-token" || secret != "request-secret" {
+		t.Fatal("Wanted request-token but got", token, secret)
+	}
+
+	authorizeURL := oauth.AuthorizeURL(token)
+	if authorizeURL != testServer.URL+"/jira/plugins/servlet/oauth/authorize?oauth_token=request-token" {
+		t.Fatal("Wanted the authorize URL but got", authorizeURL)
+	}
+
+	if _, _, err := oauth.GetAccessTokenFromVerifier(token, "verifier"); err != nil {
+		t.Fatal(err)
+	}
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := New(addr, WithScheme("http"), WithBasePath("jira"), WithAPIVersion("2"), WithAuthenticator(oauth))
+	issues, err := c.GetIssueService().Search("project = TEST", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 {
+		t.Fatal("Wanted 1 issue but got", len(issues))
+	}
+}
+
+func TestOAuth1BaseString(t *testing.T) {
+	// the example of RFC 5849, section 3.4.1.1
+	u, err := url.Parse("http://EXAMPLE.COM:80/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := u.Query()
+	params.Add("c2", "")
+	params.Add("a3", "2 q")
+	params.Set("oauth_consumer_key", "9djdj82h48djs9d2")
+	params.Set("oauth_token", "kkk9d7dh3k39sjv7")
+	params.Set("oauth_signature_method", "HMAC-SHA1")
+	params.Set("oauth_timestamp", "137131201")
+	params.Set("oauth_nonce", "7d8f3e4a")
+
+	want := "POST&http%3A%2F%2Fexample.com%2Frequest&a2%3Dr%2520b%26a3%3D2%2520q%26a3%3Da%26b5%3D%253D%25253D%26c%2540%3D%26c2%3D%26oauth_consumer_key%3D9djdj82h48djs9d2%26oauth_nonce%3D7d8f3e4a%26oauth_signature_method%3DHMAC-SHA1%26oauth_timestamp%3D137131201%26oauth_token%3Dkkk9d7dh3k39sjv7"
+	if got := oauth1BaseString("post", u, params); got != want {
+		t.Fatal("Wanted", want, "but got", got)
+	}
+
+	// names sort before longer names they prefix
+	u, _ = url.Parse("http://example.com/request?a2=x&a=y")
+	want = "GET&http%3A%2F%2Fexample.com%2Frequest&a%3Dy%26a2%3Dx"
+	if got := oauth1BaseString("GET", u, u.Query()); got != want {
+		t.Fatal("Wanted", want, "but got", got)
+	}
+}
@@ -0,0 +1,253 @@
+package jira
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/retry.v1"
+)
+
+// defaultRefreshSkew is how long before it expires the access token is refreshed,
+// so that a request does not reach Jira with a token that ran out on the way.
+const defaultRefreshSkew = time.Minute
+
+// Option configures a client created with New.
+type Option func(*options)
+
+// options collects the settings of New before the client is built,
+// so that the order of the options does not matter.
+type options struct {
+	client        *client
+	transport     http.RoundTripper
+	timeout       time.Duration
+	refreshSkew   time.Duration
+	tokenStore    TokenStore
+	cloudID       string
+	authenticator Authenticator
+}
+
+// New returns a client for the Jira site at domain, e.g. "your-domain.atlassian.net".
+// Without options it talks https to the site, uses auth.atlassian.com as the auth server
+// and sends requests with a shared http.Client.
+func New(domain string, opts ...Option) Client {
+	return newClient(domain, opts...)
+}
+
+func newClient(domain string, opts ...Option) *client {
+	c := &client{
+		baseURL:       domain,
+		site:          domain,
+		gatewayURL:    apiGateway,
+		apiVersion:    "3",
+		deployment:    DeploymentCloud,
+		authURL:       "auth.atlassian.com",
+		scheme:        "https",
+		httpClient:    &http.Client{},
+		retryStrategy: attempts,
+		logger:        nopLogger{},
+		now:           time.Now,
+	}
+	o := &options{client: c, refreshSkew: defaultRefreshSkew}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.transport != nil || o.timeout != 0 {
+		// copy the http.Client, it may be shared with code outside of this package
+		httpClient := *c.httpClient
+		if o.transport != nil {
+			httpClient.Transport = o.transport
+		}
+		if o.timeout != 0 {
+			httpClient.Timeout = o.timeout
+		}
+		c.httpClient = &httpClient
+	}
+
+	c.logger = newRedactingLogger(c.logger)
+
+	if o.cloudID != "" {
+		c.routeToCloudID(o.cloudID)
+	}
+
+	auth := &AuthImpl{client: c, refreshSkew: o.refreshSkew, store: o.tokenStore}
+	c.authService = auth
+	c.authenticator = auth
+	if o.authenticator != nil {
+		c.authenticator = o.authenticator
+	}
+	c.issueService = &IssueImpl{client: c}
+	c.worklogService = &WorklogImpl{client: c}
+
+	return c
+}
+
+// WithScheme sets the URL scheme used for the site and the auth server. Default: https.
+func WithScheme(scheme string) Option {
+	return func(o *options) {
+		o.client.scheme = scheme
+	}
+}
+
+// WithAuthDomain sets the host of the OAuth 2.0 auth server. Default: auth.atlassian.com.
+func WithAuthDomain(authDomain string) Option {
+	return func(o *options) {
+		o.client.authURL = authDomain
+	}
+}
+
+// WithOAuthApp sets the credentials of the OAuth 2.0 (3LO) app the client authenticates as.
+func WithOAuthApp(clientID string, clientSecret string, redirectURI string) Option {
+	return func(o *options) {
+		o.client.clientID = clientID
+		o.client.clientSecret = clientSecret
+		o.client.redirectURI = redirectURI
+	}
+}
+
+// WithHTTPClient sets the http.Client used for every request, e.g. one that goes
+// through a corporate proxy or trusts custom TLS roots.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		if httpClient != nil {
+			o.client.httpClient = httpClient
+		}
+	}
+}
+
+// WithTransport sets the RoundTripper of the http.Client.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithTimeout limits the time of every single HTTP request, retries not included.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.client.userAgent = userAgent
+	}
+}
+
+// WithRetryStrategy replaces the default retry strategy of the client.
+// Use retry.LimitCount(1, retry.Regular{}) to disable retries. Renewing the credentials
+// after a 401 does not count as a retry and happens with any strategy.
+func WithRetryStrategy(strategy retry.Strategy) Option {
+	return func(o *options) {
+		if strategy != nil {
+			o.client.retryStrategy = strategy
+		}
+	}
+}
+
+// WithBasePath sets a path prefix for every REST resource, e.g. "/jira" for a
+// Jira Server installed under a context path.
+func WithBasePath(basePath string) Option {
+	return func(o *options) {
+		o.client.basePath = strings.Trim(basePath, "/")
+	}
+}
+
+// WithLogger sets the logger of the client, e.g. NewStdLogger(nil, LevelInfo).
+// By default the client logs nothing. Tokens, client secrets and authorization codes
+// are redacted before a message reaches logger.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.client.logger = logger
+	}
+}
+
+// WithCloudID routes every request through the api.atlassian.com gateway to the
+// Jira Cloud site with cloudID, as OAuth 2.0 (3LO) tokens require.
+// Use Client.UseCloudSite to look the cloud ID up instead.
+func WithCloudID(cloudID string) Option {
+	return func(o *options) {
+		o.cloudID = cloudID
+	}
+}
+
+// WithAPIGateway sets the host of the API gateway. Default: api.atlassian.com.
+func WithAPIGateway(gateway string) Option {
+	return func(o *options) {
+		o.client.gatewayURL = gateway
+	}
+}
+
+// WithTokenRefreshSkew sets how long before it expires the access token is refreshed.
+// Default: one minute.
+func WithTokenRefreshSkew(skew time.Duration) Option {
+	return func(o *options) {
+		o.refreshSkew = skew
+	}
+}
+
+// WithAuthenticator signs every request with authenticator instead of the OAuth 2.0
+// tokens of the AuthService.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(o *options) {
+		o.authenticator = authenticator
+	}
+}
+
+// WithBasicAuth authenticates with the email address and an API token of an Atlassian
+// account, e.g. for bots. It is short for WithAuthenticator(NewBasicAuth(email, apiToken)).
+func WithBasicAuth(email string, apiToken string) Option {
+	return WithAuthenticator(NewBasicAuth(email, apiToken))
+}
+
+// WithPersonalAccessToken authenticates with a Personal Access Token of Jira Server
+// or Data Center. It is short for WithAuthenticator(NewPersonalAccessToken(token)).
+func WithPersonalAccessToken(token string) Option {
+	return WithAuthenticator(NewPersonalAccessToken(token))
+}
+
+// WithAPIVersion sets the version of the platform REST API, "3" or "2". Default: 3.
+func WithAPIVersion(version string) Option {
+	return func(o *options) {
+		o.client.apiVersion = version
+	}
+}
+
+// WithDataCenter configures the client for Jira Server or Data Center: requests use
+// the REST API version 2, which is the only one those serve, and authenticate with
+// the Personal Access Token pat. Combine it with WithScheme and WithBasePath as needed.
+func WithDataCenter(pat string) Option {
+	return func(o *options) {
+		o.client.deployment = DeploymentServer
+		o.client.apiVersion = "2"
+		o.authenticator = NewPersonalAccessToken(pat)
+	}
+}
+
+// WithTokenStore makes the client load its tokens from store and save them there
+// whenever they change, see TokenStore.
+func WithTokenStore(store TokenStore) Option {
+	return func(o *options) {
+		o.tokenStore = store
+	}
+}
+
+// WithClock replaces time.Now for the token expiry, mostly useful in tests.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		if now != nil {
+			o.client.now = now
+		}
+	}
+}
+
+// WithRateLimiter makes every request wait for limiter before it is sent, e.g. a
+// TokenBucket. Share one limiter between clients to limit them together.
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(o *options) {
+		o.client.limiter = limiter
+	}
+}
@@ -0,0 +1,192 @@
+package jira
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultIdleTimeout is how long a client of a Pool may go unused before it is evicted.
+const defaultIdleTimeout = 30 * time.Minute
+
+// Tenant identifies whom a client of a Pool acts for: an account on a Jira site.
+type Tenant struct {
+	Site    string
+	Account string
+	// CloudID routes the client through the api.atlassian.com gateway to the Jira Cloud
+	// site, as OAuth 2.0 tokens require, see WithCloudID. Look it up once with
+	// Client.GetAccessibleResources and keep it with the tenant. Leave it empty for
+	// Jira Server, Data Center and API token authentication.
+	CloudID string
+}
+
+// TenantTokenStore returns the TokenStore that keeps the tokens of tenant.
+type TenantTokenStore func(tenant Tenant) TokenStore
+
+// NewMemoryTenantTokenStore returns a TenantTokenStore that keeps the tokens of every
+// tenant in memory, so that they survive the eviction of its client but not a restart.
+func NewMemoryTenantTokenStore() TenantTokenStore {
+	var mu sync.Mutex
+	stores := map[Tenant]*MemoryTokenStore{}
+	return func(tenant Tenant) TokenStore {
+		mu.Lock()
+		defer mu.Unlock()
+		store, ok := stores[tenant]
+		if !ok {
+			store = NewMemoryTokenStore()
+			stores[tenant] = store
+		}
+		return store
+	}
+}
+
+// NewFileTenantTokenStore returns a TenantTokenStore that keeps the tokens of every
+// tenant in its own FileTokenStore in dir. The file names are hashes, site and account
+// names never reach the file system.
+func NewFileTenantTokenStore(dir string) TenantTokenStore {
+	return func(tenant Tenant) TokenStore {
+		sum := sha256.Sum256([]byte(tenant.Site + "\x00" + tenant.Account))
+		return NewFileTokenStore(filepath.Join(dir, hex.EncodeToString(sum[:])+".json"))
+	}
+}
+
+// PoolOption configures a Pool created with NewPool.
+type PoolOption func(*Pool)
+
+// WithPoolTokenStore loads and saves the tokens of every tenant through store.
+// By default they are kept in memory, see NewMemoryTenantTokenStore.
+func WithPoolTokenStore(store TenantTokenStore) PoolOption {
+	return func(p *Pool) {
+		p.store = store
+	}
+}
+
+// WithPoolIdleTimeout evicts clients that were not used for d. The default is 30 minutes,
+// zero or less keeps clients until they are removed.
+func WithPoolIdleTimeout(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.idleTimeout = d
+	}
+}
+
+// WithPoolClientOptions applies opts to every client of the pool, e.g. WithOAuthApp.
+func WithPoolClientOptions(opts ...Option) PoolOption {
+	return func(p *Pool) {
+		p.clientOpts = append(p.clientOpts, opts...)
+	}
+}
+
+// Pool hands out one client per tenant for services that act on behalf of many users
+// across Jira sites. All clients share one http.Client and thus its connections, unless
+// WithHTTPClient is among the client options. Clients that were idle for longer than the
+// idle timeout are evicted on the way; their tokens stay in the TenantTokenStore.
+// It is safe for concurrent use.
+//
+// Do not hold on to a client beyond the work at hand: once it was evicted, the next Get
+// creates a new client for the tenant and both share the tokens in the store. A client
+// reads the store again before it refreshes and so picks up a refresh token the other
+// one rotated, but two clients refreshing at the same moment still spend the same
+// refresh token and one of them fails with ErrUnauthorized.
+type Pool struct {
+	httpClient  *http.Client
+	clientOpts  []Option
+	store       TenantTokenStore
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	clients   map[Tenant]*poolEntry
+	lastSweep time.Time
+}
+
+type poolEntry struct {
+	client   Client
+	lastUsed time.Time
+}
+
+// NewPool returns an empty Pool.
+func NewPool(opts ...PoolOption) *Pool {
+	p := &Pool{
+		httpClient:  &http.Client{},
+		idleTimeout: defaultIdleTimeout,
+		now:         time.Now,
+		clients:     map[Tenant]*poolEntry{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.store == nil {
+		p.store = NewMemoryTenantTokenStore()
+	}
+	p.lastSweep = p.now()
+	return p
+}
+
+// Get returns the client of tenant, creating it for the site of the tenant if there is
+// none yet. Its AuthService reads and writes the tokens of the tenant.
+func (p *Pool) Get(tenant Tenant) Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if p.idleTimeout > 0 && now.Sub(p.lastSweep) >= p.idleTimeout {
+		p.evictIdleLocked(now)
+	}
+
+	entry, ok := p.clients[tenant]
+	if !ok {
+		opts := make([]Option, 0, len(p.clientOpts)+3)
+		// the shared http.Client comes first so that the client options can replace it
+		opts = append(opts, WithHTTPClient(p.httpClient))
+		opts = append(opts, p.clientOpts...)
+		opts = append(opts, WithTokenStore(p.store(tenant)))
+		if tenant.CloudID != "" {
+			opts = append(opts, WithCloudID(tenant.CloudID))
+		}
+		entry = &poolEntry{client: New(tenant.Site, opts...)}
+		p.clients[tenant] = entry
+	}
+	entry.lastUsed = now
+	return entry.client
+}
+
+// Remove drops the client of tenant, e.g. after the user logged out.
+func (p *Pool) Remove(tenant Tenant) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clients, tenant)
+}
+
+// Len returns the number of clients in the pool.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.clients)
+}
+
+// EvictIdle drops the clients that were not used for the idle timeout and returns how
+// many were dropped. Get does this on its own, call it to free memory between bursts.
+func (p *Pool) EvictIdle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.evictIdleLocked(p.now())
+}
+
+// evictIdleLocked drops the idle clients. p.mu must be held.
+func (p *Pool) evictIdleLocked(now time.Time) int {
+	p.lastSweep = now
+	if p.idleTimeout <= 0 {
+		return 0
+	}
+	evicted := 0
+	for tenant, entry := range p.clients {
+		if now.Sub(entry.lastUsed) >= p.idleTimeout {
+			delete(p.clients, tenant)
+			evicted++
+		}
+	}
+	return evicted
+}
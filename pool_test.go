@@ -0,0 +1,127 @@
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	p := NewPool(WithPoolIdleTimeout(time.Minute), WithPoolClientOptions(WithOAuthApp("id", "secret", "http://localhost/callback")))
+	p.now = func() time.Time { return now }
+
+	alice := Tenant{Site: "one.atlassian.net", Account: "alice"}
+	bob := Tenant{Site: "one.atlassian.net", Account: "bob"}
+
+	c := p.Get(alice)
+	if p.Get(alice) != c {
+		t.Fatal("Wanted the same client for the same tenant")
+	}
+	if p.Get(bob) == c {
+		t.Fatal("Wanted another client for another account")
+	}
+	if c.(*client).httpClient != p.Get(bob).(*client).httpClient {
+		t.Fatal("Wanted the clients to share the http.Client")
+	}
+	if p.Len() != 2 {
+		t.Fatal("Wanted 2 clients but got", p.Len())
+	}
+
+	c.GetAuthService().SetRefreshToken("alice-refresh")
+
+	now = now.Add(30 * time.Second)
+	p.Get(bob)
+	now = now.Add(45 * time.Second)
+	if evicted := p.EvictIdle(); evicted != 1 {
+		t.Fatal("Wanted 1 idle client to be evicted but got", evicted)
+	}
+
+	again := p.Get(alice)
+	if again == c {
+		t.Fatal("Wanted a new client after eviction")
+	}
+	if again.GetAuthService().GetRefreshToken() != "alice-refresh" {
+		t.Fatal("Wanted the token to survive the eviction but got", again.GetAuthService().GetRefreshToken())
+	}
+
+	// the lazy sweep of Get drops bob
+	now = now.Add(2 * time.Minute)
+	p.Get(alice)
+	if p.Len() != 1 {
+		t.Fatal("Wanted 1 client after the sweep but got", p.Len())
+	}
+}
+
+func TestFileTenantTokenStore(t *testing.T) {
+	store := NewFileTenantTokenStore(t.TempDir())
+	p := NewPool(WithPoolTokenStore(store))
+
+	tenant := Tenant{Site: "one.atlassian.net", Account: "alice"}
+	p.Get(tenant).GetAuthService().SetRefreshToken("alice-refresh")
+	p.Remove(tenant)
+
+	if got := NewPool(WithPoolTokenStore(store)).Get(tenant).GetAuthService().GetRefreshToken(); got != "alice-refresh" {
+		t.Fatal("Wanted alice-refresh but got", got)
+	}
+	if got := p.Get(Tenant{Site: "one.atlassian.net", Account: "bob"}).GetAuthService().GetRefreshToken(); got != "" {
+		t.Fatal("Wanted no token for bob but got", got)
+	}
+}
+
+func TestPool_CloudID(t *testing.T) {
+	p := NewPool()
+
+	c := p.Get(Tenant{Site: "one.atlassian.net", Account: "alice", CloudID: "cloud-1"}).(*client)
+	if c.getBaseURL() != apiGateway || c.basePath != "ex/jira/cloud-1" {
+		t.Fatal("Wanted the client to route through the gateway to cloud-1 but got", c.getBaseURL(), c.basePath)
+	}
+
+	c = p.Get(Tenant{Site: "jira.example.com", Account: "bob"}).(*client)
+	if c.getBaseURL() != "jira.example.com" || c.basePath != "" {
+		t.Fatal("Wanted the client to talk to the site but got", c.getBaseURL(), c.basePath)
+	}
+}
+
+func TestPool_EvictedClientPicksUpRotatedToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		var payload OAuthRefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		switch payload.RefreshToken {
+		case "refresh-1":
+			_ = json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "access-2", RefreshToken: "refresh-2", ExpiresIn: 1})
+		case "refresh-2":
+			_ = json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "access-3", RefreshToken: "refresh-3", ExpiresIn: 3600})
+		default:
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		}
+	})
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	p := NewPool(WithPoolClientOptions(WithScheme("http"), WithAuthDomain(addr), WithOAuthApp("test", "test", "test")))
+	tenant := Tenant{Site: addr, Account: "alice"}
+
+	old := p.Get(tenant)
+	old.GetAuthService().SetRefreshToken("refresh-1")
+	p.Remove(tenant)
+
+	// the new client rotates the refresh token while the caller still holds the old one
+	if _, err := p.Get(tenant).GetAuthService().GetAccessTokenFromRefreshToken(); err != nil {
+		t.Fatal(err)
+	}
+	token, err := old.GetAuthService().GetAccessTokenFromRefreshToken()
+	if err != nil {
+		t.Fatal("Wanted the old client to refresh with the rotated token but got", err)
+	}
+	if token.AccessToken != "access-3" {
+		t.Fatal("Wanted access-3 but got", token.AccessToken)
+	}
+}
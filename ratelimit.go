@@ -0,0 +1,73 @@
+package jira
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles requests on the client side, before they reach Jira.
+type RateLimiter interface {
+	// Wait blocks until the next request may be sent or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a RateLimiter that allows bursts of up to burst requests
+// and refills at a steady rate of requests per second.
+// It is safe for concurrent use and can be shared by several clients.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewTokenBucket returns a TokenBucket that starts full.
+func NewTokenBucket(requestsPerSecond float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Wait takes a token from the bucket, sleeping until one is available.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if b.rate <= 0 {
+		return ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	// take the token right away, callers that have to wait queue up behind each other
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if err := sleepContext(ctx, wait); err != nil {
+		// give the token back, the request is not going to be sent
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
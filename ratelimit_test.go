@@ -0,0 +1,39 @@
+package jira
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Wait(t *testing.T) {
+	now := time.Now()
+	bucket := NewTokenBucket(10, 2)
+	bucket.now = func() time.Time { return now }
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatal("Wanted the burst to pass without waiting but took", time.Since(start))
+	}
+
+	// the bucket is empty, the next token comes after 100ms
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatal("Wanted context.DeadlineExceeded but got", err)
+	}
+
+	now = now.Add(100 * time.Millisecond)
+	start = time.Now()
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatal("Wanted a refilled token without waiting but took", time.Since(start))
+	}
+}
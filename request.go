@@ -1,69 +1,151 @@
 package jira
 
 import (
-	"errors"
+	"context"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
-	"strconv"
+	"time"
+
+	"gopkg.in/retry.v1"
 )
 
 func (c *client) newRequest(method string, url string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, body)
+	return c.newRequestContext(context.Background(), method, url, body)
+}
+
+func (c *client) newRequestContext(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 	return req, nil
 }
 
+// sendRequest signs req with the authenticator of the client and sends it. On 401 it
+// renews the credentials once if the authenticator supports it and resends right away,
+// without using up an attempt of the retry strategy. It retries with backoff on 429,
+// and on 503 for idempotent methods, waiting at least as long as Jira asks for in
+// Retry-After.
+// Retries and the sleeps between them stop as soon as the request context is done.
 func (c *client) sendRequest(req *http.Request) (*http.Response, error) {
-	var resp *http.Response
-	log.Println("[SendRequest] Started")
-	for attempt := attempts.Start(nil); attempt.Next(); {
-		log.Println("[SendRequest] Starting Attempt:" + strconv.FormatInt(int64(attempt.Count()), 10))
+	ctx := req.Context()
+	var lastErr error
+	refreshed := false
+	for attempt := retry.StartWithCancel(c.retryStrategy, nil, ctx.Done()); attempt.Next(); {
+		c.logger.Debug("sending request", "method", req.Method, "url", req.URL.String(), "attempt", attempt.Count())
 
-		if c.GetAuthService().GetAccessToken() == "" {
-			// refresh token
-			authResp, err := c.GetAuthService().GetAccessTokenFromRefreshToken()
-			if err != nil {
-				log.Println(err)
+		resp, apiErr, err := c.send(req, attempt.Count() > 1)
+		if err != nil {
+			return nil, err
+		}
+		if apiErr != nil && apiErr.StatusCode == http.StatusUnauthorized && !refreshed {
+			// renew the credentials once, another 401 means renewing does not help
+			if refresher, ok := c.authenticator.(RefreshableAuthenticator); ok {
+				refreshed = true
+				refresher.Invalidate(req)
+				resp, apiErr, err = c.send(req, true)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if apiErr == nil {
+			c.logger.Debug("request succeeded", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode)
+			return resp, nil
+		}
+
+		lastErr = apiErr
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			// a 429 was not processed, but a 503 may come after Jira committed a write
+			if !attempt.More() || (apiErr.StatusCode == http.StatusServiceUnavailable && !isIdempotent(req.Method)) {
+				return nil, apiErr
+			}
+			// the backoff of the next attempt comes on top of this
+			if err := sleepContext(ctx, apiErr.RateLimit.RetryAfter); err != nil {
 				return nil, err
 			}
-			c.GetAuthService().SetAccessToken(authResp.AccessToken)
+			continue
 		}
+		return nil, apiErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// retries are exhausted, report the last failure
+	return nil, lastErr
+}
 
-		req.Header.Set("Authorization", "Bearer "+c.GetAuthService().GetAccessToken())
+// send waits for the rate limiter, signs req and sends it once. resend has to be set
+// when req was sent before so its body is read again from the start. Jira errors are
+// returned as apiErr with the response body already closed, err reports everything
+// that kept the request from getting an answer.
+func (c *client) send(req *http.Request, resend bool) (resp *http.Response, apiErr *Error, err error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(req.Context()); err != nil {
+			return nil, nil, err
+		}
+	}
 
-		httpClient := http.Client{}
-		var err error
-		resp, err = httpClient.Do(req)
+	if resend && req.GetBody != nil {
+		body, err := req.GetBody()
 		if err != nil {
-			log.Println("Error sending request" + err.Error())
-			return nil, err
+			return nil, nil, err
 		}
+		req.Body = body
+	}
 
-		if resp.StatusCode >= 400 {
-			bytesResp, err := ioutil.ReadAll(resp.Body)
-			if err != nil {
-				log.Println("Error reading response body" + err.Error())
-				return nil, err
-			}
+	if err := c.authenticator.Authenticate(req); err != nil {
+		c.logger.Error("authenticating request failed", "error", err)
+		return nil, nil, err
+	}
 
-			log.Println("Error body", string(bytesResp))
-			log.Println("Error calling jira api. Wanted 200 but got code " + strconv.FormatInt(int64(resp.StatusCode), 10))
-			// if error is unauthorized retry here
-			if resp.StatusCode == 401 {
-				c.GetAuthService().SetAccessToken("")
-				continue
-			}
-			return nil, errors.New("Error calling jira api. Wanted 200 but got code " + strconv.FormatInt(int64(resp.StatusCode), 10))
-		}
-		break
+	resp, err = c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("sending request failed", "method", req.Method, "url", req.URL.String(), "error", err)
+		return nil, nil, err
+	}
+	if resp.StatusCode < 400 {
+		return resp, nil, nil
+	}
+
+	bytesResp, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		c.logger.Error("reading error response failed", "status", resp.StatusCode, "error", err)
+		return nil, nil, err
+	}
+	c.logger.Warn("jira api returned an error", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "body", string(bytesResp))
+	return nil, newError(resp, bytesResp), nil
+}
+
+// isIdempotent reports whether sending a request with method twice has the same effect
+// as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// sleepContext pauses for d or until ctx is done, whichever happens first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	log.Println("[SendRequest] Ended")
-	return resp, nil
 }
@@ -1,9 +1,16 @@
 package jira
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
+
+	"gopkg.in/retry.v1"
 )
 
 func TestSendRequest(t *testing.T) {
@@ -68,3 +75,239 @@ func TestNewRequest(t *testing.T) {
 		t.Fatal("Wanted header accept to be application/json")
 	}
 }
+
+func TestSendRequestUnauthorized(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		resp := OAuthResponse{AccessToken: "revoked"}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	testMux.HandleFunc("/issues", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+	})
+
+	url := testClient.getScheme() + "://" + testClient.getBaseURL() + "/issues"
+	req, err := testClient.newRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	resp, err := testClient.sendRequest(req)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatal("Wanted ErrUnauthorized but got", err)
+	}
+
+	if resp != nil {
+		t.Fatal("Wanted no response but got", resp.StatusCode)
+	}
+}
+
+func TestSendRequestRefreshRejected(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("expired")
+	testClient.GetAuthService().SetRefreshToken("revoked")
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Unknown or invalid refresh token."}`))
+	})
+	testMux.HandleFunc("/issues", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+	})
+
+	url := testClient.getScheme() + "://" + testClient.getBaseURL() + "/issues"
+	req, err := testClient.newRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testClient.sendRequest(req)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatal("Wanted a rejected refresh to match ErrUnauthorized but got", err)
+	}
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) {
+		t.Fatal("Wanted a RefreshError but got", err)
+	}
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.StatusCode != 403 {
+		t.Fatal("Wanted the 403 of the auth server but got", err)
+	}
+}
+
+func TestSendRequestContextCanceled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		resp := OAuthResponse{AccessToken: "token"}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	calls := 0
+	testMux.HandleFunc("/issues", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		cancel()
+		w.WriteHeader(401)
+	})
+
+	url := testClient.getScheme() + "://" + testClient.getBaseURL() + "/issues"
+	req, err := testClient.newRequestContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = testClient.sendRequest(req)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("Wanted context.Canceled but got", err)
+	}
+
+	if calls != 1 {
+		t.Fatal("Wanted 1 call but got", calls)
+	}
+}
+
+func TestSendRequestRateLimited(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		resp := OAuthResponse{AccessToken: "token"}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	var first time.Time
+	calls := 0
+	testMux.HandleFunc("/issues", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(429)
+			return
+		}
+		if time.Since(first) < time.Second {
+			t.Error("Wanted the retry to wait for Retry-After but it came after", time.Since(first))
+		}
+		w.WriteHeader(200)
+	})
+
+	url := testClient.getScheme() + "://" + testClient.getBaseURL() + "/issues"
+	req, err := testClient.newRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	resp, err := testClient.sendRequest(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if resp.StatusCode != 200 || calls != 2 {
+		t.Fatal("Wanted 200 after 2 calls but got", resp.StatusCode, "after", calls)
+	}
+}
+
+func TestSendRequestUnavailable(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	calls := map[string]int{}
+	testMux.HandleFunc("/issues", func(w http.ResponseWriter, r *http.Request) {
+		calls[r.Method]++
+		if calls[r.Method] == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(200)
+	})
+
+	url := testClient.getScheme() + "://" + testClient.getBaseURL() + "/issues"
+	req, err := testClient.newRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testClient.sendRequest(req); err != nil {
+		t.Fatal("Wanted a GET to be retried after 503 but got", err)
+	}
+
+	// the POST may have been processed before the 503, sending it again could duplicate it
+	req, err = testClient.newRequest(http.MethodPost, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testClient.sendRequest(req)
+	var jiraErr *Error
+	if !errors.As(err, &jiraErr) || jiraErr.StatusCode != 503 {
+		t.Fatal("Wanted the 503 of the POST but got", err)
+	}
+	if calls[http.MethodPost] != 1 {
+		t.Fatal("Wanted the POST to be sent once but got", calls[http.MethodPost], "calls")
+	}
+}
+
+func TestSendRequestRefreshWithoutRetries(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.retryStrategy = retry.LimitCount(1, retry.Regular{Delay: time.Hour})
+	testClient.GetAuthService().SetAccessToken("expired")
+	testClient.GetAuthService().SetRefreshToken("refresh")
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "fresh", RefreshToken: "refresh"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	calls := 0
+	testMux.HandleFunc("/issues", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(401)
+			return
+		}
+		if string(body) != `{"summary":"test"}` {
+			t.Error("Wanted the body to be sent again but got", string(body))
+		}
+		w.WriteHeader(201)
+	})
+
+	url := testClient.getScheme() + "://" + testClient.getBaseURL() + "/issues"
+	req, err := testClient.newRequest(http.MethodPost, url, strings.NewReader(`{"summary":"test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the renewal must neither use up the only attempt nor wait for the backoff
+	resp, err := testClient.sendRequest(req)
+	if err != nil {
+		t.Fatal("Wanted the request to succeed after refreshing but got", err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatal("Wanted", 201, "but got", resp.StatusCode)
+	}
+	if calls != 2 {
+		t.Fatal("Wanted", 2, "calls but got", calls)
+	}
+}
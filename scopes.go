@@ -0,0 +1,103 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The classic OAuth 2.0 scopes of the Jira platform REST API.
+const (
+	ScopeReadJiraWork      = "read:jira-work"
+	ScopeWriteJiraWork     = "write:jira-work"
+	ScopeReadJiraUser      = "read:jira-user"
+	ScopeManageJiraProject = "manage:jira-project"
+	ScopeOfflineAccess     = "offline_access"
+)
+
+// ErrMissingScope is matched by MissingScopeError.
+var ErrMissingScope = errors.New("jira: missing oauth scope")
+
+// operationScopes maps the service methods to the classic scopes they need. The search
+// methods of IssueService share the entry of Search, the deprecated IssueService.Update
+// the one of WorklogService.Add. Operations that are not listed are not checked.
+// It is read by every request and must not be changed.
+var operationScopes = map[string][]string{
+	"IssueService.Get":            {ScopeReadJiraWork},
+	"IssueService.Search":         {ScopeReadJiraWork},
+	"WorklogService.List":         {ScopeReadJiraWork},
+	"WorklogService.Get":          {ScopeReadJiraWork},
+	"WorklogService.Add":          {ScopeWriteJiraWork},
+	"WorklogService.Update":       {ScopeWriteJiraWork},
+	"WorklogService.Delete":       {ScopeWriteJiraWork},
+	"WorklogService.ListByIDs":    {ScopeReadJiraWork},
+	"WorklogService.SyncIterator": {ScopeReadJiraWork},
+}
+
+// RequiredScopes returns the classic scopes the service method operation needs, e.g.
+// "WorklogService.Add", or nil if it is not checked. Use it to ask for the right scopes
+// in NewAuthorizationRequest.
+func RequiredScopes(operation string) []string {
+	scopes := operationScopes[operation]
+	if scopes == nil {
+		return nil
+	}
+	return append([]string(nil), scopes...)
+}
+
+// MissingScopeError is returned before a request is sent when the access token was
+// granted without a scope the operation needs. Ask the user to authorize the app again
+// with the missing scopes, e.g. with NewAuthorizationRequest.
+type MissingScopeError struct {
+	Operation string
+	Missing   []string
+	Granted   []string
+}
+
+func (e *MissingScopeError) Error() string {
+	return fmt.Sprintf("jira: %s needs the scopes %s, granted are %s",
+		e.Operation, strings.Join(e.Missing, " "), strings.Join(e.Granted, " "))
+}
+
+// Is makes MissingScopeError match ErrMissingScope.
+func (e *MissingScopeError) Is(target error) bool {
+	return target == ErrMissingScope
+}
+
+// checkScopes returns a MissingScopeError if the client authenticates with OAuth 2.0 and
+// the granted scopes are known to lack one operation needs.
+func (c *client) checkScopes(operation string) error {
+	auth, ok := c.authenticator.(*AuthImpl)
+	if !ok {
+		return nil
+	}
+	granted := auth.GetScopes()
+	missing := missingScopes(operationScopes[operation], granted)
+	if len(missing) == 0 {
+		return nil
+	}
+	return &MissingScopeError{Operation: operation, Missing: missing, Granted: granted}
+}
+
+// missingScopes returns the required scopes that were not granted. Nothing is reported
+// when no scopes are known or the app uses granular scopes such as read:issue:jira,
+// which this package does not map.
+func missingScopes(required []string, granted []string) []string {
+	if len(granted) == 0 {
+		return nil
+	}
+	has := make(map[string]bool, len(granted))
+	for _, scope := range granted {
+		if strings.Count(scope, ":") >= 2 {
+			return nil
+		}
+		has[scope] = true
+	}
+	var missing []string
+	for _, scope := range required {
+		if !has[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,72 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestMissingScope(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "token", Scope: "read:jira-work offline_access", ExpiresIn: 3600})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	testMux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"issues":[]}`))
+	})
+	testMux.HandleFunc("/rest/api/3/issue/TEST-1/worklog", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("Did not want the worklog request without write:jira-work")
+	})
+
+	auth := testClient.GetAuthService()
+	if len(auth.GetScopes()) != 0 {
+		t.Fatal("Wanted no scopes before the first token but got", auth.GetScopes())
+	}
+	if _, err := auth.GetAccessTokenFromAuthorizationCode("code"); err != nil {
+		t.Fatal(err)
+	}
+	if scopes := auth.GetScopes(); len(scopes) != 2 || scopes[0] != ScopeReadJiraWork {
+		t.Fatal("Wanted the granted scopes but got", scopes)
+	}
+
+	if _, err := testClient.GetIssueService().Search("test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	err := testClient.GetIssueService().Update("TEST-1", "1h")
+	var scopeErr *MissingScopeError
+	if !errors.Is(err, ErrMissingScope) || !errors.As(err, &scopeErr) {
+		t.Fatal("Wanted a MissingScopeError but got", err)
+	}
+	if len(scopeErr.Missing) != 1 || scopeErr.Missing[0] != ScopeWriteJiraWork {
+		t.Fatal("Wanted", ScopeWriteJiraWork, "to be missing but got", scopeErr.Missing)
+	}
+}
+
+func TestMissingScopes_Granular(t *testing.T) {
+	missing := missingScopes([]string{ScopeWriteJiraWork}, []string{"read:issue-worklog:jira", "write:issue-worklog:jira"})
+	if len(missing) != 0 {
+		t.Fatal("Wanted granular scopes not to be checked but got", missing)
+	}
+}
+
+func TestRequiredScopes(t *testing.T) {
+	scopes := RequiredScopes("WorklogService.Add")
+	if len(scopes) != 1 || scopes[0] != ScopeWriteJiraWork {
+		t.Fatal("Wanted", ScopeWriteJiraWork, "but got", scopes)
+	}
+	scopes[0] = ScopeReadJiraWork
+	if RequiredScopes("WorklogService.Add")[0] != ScopeWriteJiraWork {
+		t.Fatal("Wanted RequiredScopes to return a copy")
+	}
+	if RequiredScopes("IssueService.Unknown") != nil {
+		t.Fatal("Wanted no scopes for an unknown operation")
+	}
+}
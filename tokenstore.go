@@ -0,0 +1,155 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrNoToken is returned by TokenStore.Load when no token was saved yet.
+var ErrNoToken = errors.New("jira: no token stored")
+
+// Token is the OAuth 2.0 token pair that a TokenStore keeps between runs.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+}
+
+// TokenStore persists the tokens of an AuthImpl. The AuthImpl loads them before it
+// first needs a token and saves them whenever they change, including the new refresh
+// token the auth server hands out on every refresh when rotating refresh tokens are on.
+type TokenStore interface {
+	// Load returns the saved token, or ErrNoToken if there is none.
+	Load(ctx context.Context) (*Token, error)
+	Save(ctx context.Context, token *Token) error
+	// Delete removes the saved token. Deleting when there is none is not an error.
+	Delete(ctx context.Context) error
+}
+
+// MemoryTokenStore keeps the token in memory, e.g. to share it between clients.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (s *MemoryTokenStore) Load(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == nil {
+		return nil, ErrNoToken
+	}
+	token := *s.token
+	return &token, nil
+}
+
+func (s *MemoryTokenStore) Save(ctx context.Context, token *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := *token
+	s.token = &t
+	return nil
+}
+
+func (s *MemoryTokenStore) Delete(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = nil
+	return nil
+}
+
+// FileTokenStore keeps the token as JSON in a file that only the owner can read.
+// Writes are atomic, a crash never leaves a half written token behind.
+type FileTokenStore struct {
+	path string
+}
+
+// NewFileTokenStore returns a FileTokenStore for the file at path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+func (s *FileTokenStore) Load(ctx context.Context) (*Token, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *FileTokenStore) Save(ctx context.Context, token *Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+func (s *FileTokenStore) Delete(ctx context.Context) error {
+	return removeFile(s.path)
+}
+
+// removeFile removes the file at path, if it exists.
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file with mode 0600 next to path
+// and renames it over path once it is completely on disk.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	// TempFile already creates the file with 0600, make sure of it anyway
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package jira
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// TokenKeyEnv is the environment variable KeyFromEnv reads by default.
+const TokenKeyEnv = "JIRA_TOKEN_KEY"
+
+// encryptedTokenVersion prefixes the file, so the format can change later on.
+const encryptedTokenVersion byte = 1
+
+// encryptedTokenAAD binds the ciphertext to its purpose.
+var encryptedTokenAAD = []byte("jira-gophers token")
+
+// EncryptedFileTokenStore keeps the token in a file encrypted with AES-GCM, so that
+// tools running on laptops do not leave plaintext refresh tokens in home directories.
+// Like FileTokenStore, the file is only readable by its owner and written atomically.
+type EncryptedFileTokenStore struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewEncryptedFileTokenStore returns a store for the file at path, encrypted with key.
+// The key must be 16, 24 or 32 bytes long for AES-128, AES-192 or AES-256,
+// see KeyFromEnv and KeyFromFile.
+func NewEncryptedFileTokenStore(path string, key []byte) (*EncryptedFileTokenStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedFileTokenStore{path: path, aead: aead}, nil
+}
+
+func (s *EncryptedFileTokenStore) Load(ctx context.Context) (*Token, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(data) < 1+nonceSize || data[0] != encryptedTokenVersion {
+		return nil, errors.New("jira: unknown encrypted token format in " + s.path)
+	}
+	plaintext, err := s.aead.Open(nil, data[1:1+nonceSize], data[1+nonceSize:], encryptedTokenAAD)
+	if err != nil {
+		return nil, fmt.Errorf("jira: decrypting %s failed, wrong key?: %w", s.path, err)
+	}
+
+	var token Token
+	if err := json.Unmarshal(plaintext, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *EncryptedFileTokenStore) Save(ctx context.Context, token *Token) error {
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := append([]byte{encryptedTokenVersion}, nonce...)
+	data = s.aead.Seal(data, nonce, plaintext, encryptedTokenAAD)
+	return writeFileAtomic(s.path, data)
+}
+
+func (s *EncryptedFileTokenStore) Delete(ctx context.Context) error {
+	return removeFile(s.path)
+}
+
+// KeyFromEnv reads the key for an EncryptedFileTokenStore from the environment variable
+// name, TokenKeyEnv if name is empty. The value is the base64 or hex encoded key.
+func KeyFromEnv(name string) ([]byte, error) {
+	if name == "" {
+		name = TokenKeyEnv
+	}
+	value := os.Getenv(name)
+	if value == "" {
+		return nil, errors.New("jira: environment variable " + name + " is not set")
+	}
+	return decodeKey(value)
+}
+
+// KeyFromFile reads the key for an EncryptedFileTokenStore from the file at path,
+// which holds the base64 or hex encoding of the key, decoded like KeyFromEnv does, or the
+// raw key if it is not a valid encoding.
+// Like ssh, it refuses key files that others than the owner can read.
+func KeyFromFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("jira: key file %s has mode %v, it must not be accessible by group or others", path, info.Mode().Perm())
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// an encoded key reads the same as through KeyFromEnv, the raw key is the fallback
+	key, err := decodeKey(string(data))
+	if err != nil && isKeySize(len(data)) {
+		return data, nil
+	}
+	return key, err
+}
+
+// decodeKey decodes a base64 or hex encoded AES key.
+func decodeKey(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if key, err := hex.DecodeString(value); err == nil && isKeySize(len(key)) {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && isKeySize(len(key)) {
+		return key, nil
+	}
+	if key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "=")); err == nil && isKeySize(len(key)) {
+		return key, nil
+	}
+	return nil, errors.New("jira: the key must be a base64 or hex encoded 16, 24 or 32 byte AES key")
+}
+
+func isKeySize(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
@@ -0,0 +1,222 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jira-gophers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileTokenStore(filepath.Join(dir, "tokens", "jira.json"))
+
+	_, err = store.Load(context.Background())
+	if err != ErrNoToken {
+		t.Fatal("Wanted ErrNoToken but got", err)
+	}
+
+	expected := Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		Expiry:       time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC),
+		Scope:        "read:jira-work offline_access",
+	}
+	if err := store.Save(context.Background(), &expected); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "tokens", "jira.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatal("Wanted file mode 0600 but got", info.Mode().Perm())
+	}
+
+	actual, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.AccessToken != expected.AccessToken || actual.RefreshToken != expected.RefreshToken || !actual.Expiry.Equal(expected.Expiry) || actual.Scope != expected.Scope {
+		t.Fatal("Wanted", expected, "but got", *actual)
+	}
+}
+
+func TestAuthImpl_TokenStore(t *testing.T) {
+	setup()
+	defer teardown()
+
+	store := NewMemoryTokenStore()
+	err := store.Save(context.Background(), &Token{RefreshToken: "stored-refresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := newClient(addr,
+		WithScheme("http"),
+		WithAuthDomain(addr),
+		WithOAuthApp("test", "test", "test"),
+		WithTokenStore(store),
+	)
+
+	testMux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		var payload OAuthRefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.RefreshToken != "stored-refresh" {
+			t.Error("Wanted the stored refresh token but got", payload.RefreshToken)
+		}
+		w.WriteHeader(200)
+		err := json.NewEncoder(w).Encode(&OAuthResponse{AccessToken: "access", RefreshToken: "rotated-refresh", ExpiresIn: 3600})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	token, err := c.GetAuthService().GetValidAccessToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "access" {
+		t.Fatal("Wanted access but got", token)
+	}
+
+	saved, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.RefreshToken != "rotated-refresh" || saved.AccessToken != "access" || saved.Expiry.IsZero() {
+		t.Fatal("Wanted the rotated tokens to be saved but got", *saved)
+	}
+}
+
+func TestEncryptedFileTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jira-gophers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv(TokenKeyEnv, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	defer os.Unsetenv(TokenKeyEnv)
+
+	key, err := KeyFromEnv("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "jira.token")
+	store, err := NewEncryptedFileTokenStore(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Load(context.Background()); err != ErrNoToken {
+		t.Fatal("Wanted ErrNoToken but got", err)
+	}
+
+	if err := store.Save(context.Background(), &Token{AccessToken: "access", RefreshToken: "plaintext-refresh"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "plaintext-refresh") {
+		t.Fatal("Wanted the refresh token to be encrypted on disk")
+	}
+
+	actual, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.RefreshToken != "plaintext-refresh" || actual.AccessToken != "access" {
+		t.Fatal("Wanted the saved tokens but got", *actual)
+	}
+
+	wrongKey := make([]byte, 32)
+	other, err := NewEncryptedFileTokenStore(path, wrongKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Load(context.Background()); err == nil {
+		t.Fatal("Wanted decryption with the wrong key to fail")
+	}
+}
+
+func TestKeyFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jira-gophers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(path, []byte("AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := KeyFromFile(path); err == nil {
+		t.Fatal("Wanted a world readable key file to be refused")
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := KeyFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 || key[31] != 0x1f {
+		t.Fatal("Wanted the decoded 32 byte key but got", key)
+	}
+
+	// a hex AES-128 key without newline is 32 bytes long, but must still be decoded
+	hexKey := "000102030405060708090a0b0c0d0e0f"
+	if err := ioutil.WriteFile(path, []byte(hexKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("JIRA_TEST_KEY", hexKey)
+	defer os.Unsetenv("JIRA_TEST_KEY")
+	fromFile, err := KeyFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromEnv, err := KeyFromEnv("JIRA_TEST_KEY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fromFile) != 16 || !bytes.Equal(fromFile, fromEnv) {
+		t.Fatal("Wanted the same 16 byte key from file and environment but got", fromFile, fromEnv)
+	}
+
+	// raw key bytes that are no valid encoding are taken as they are
+	raw := make([]byte, 32)
+	raw[0] = 0xff
+	if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+	key, err = KeyFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, raw) {
+		t.Fatal("Wanted the raw key but got", key)
+	}
+}
@@ -0,0 +1,215 @@
+package jira
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// maxWorklogIDs is how many worklogs /worklog/list returns at most per request.
+const maxWorklogIDs = 1000
+
+// WorklogCheckpoint is where a worklog sync stopped. Save it after the changes were
+// applied and pass it to the next Sync to only get what changed since.
+type WorklogCheckpoint struct {
+	UpdatedSince time.Time `json:"updatedSince"`
+	DeletedSince time.Time `json:"deletedSince"`
+}
+
+// WorklogChanges are the worklogs that changed on one page of a worklog sync.
+type WorklogChanges struct {
+	// Updated are the worklogs that were added or changed, across all issues
+	Updated []WorklogRecord
+	// Deleted are the IDs of the worklogs that were deleted
+	Deleted []int64
+	// Checkpoint is where the sync continues after this page
+	Checkpoint WorklogCheckpoint
+}
+
+// worklogChange is an entry of /worklog/updated and /worklog/deleted.
+type worklogChange struct {
+	WorklogID   int64            `json:"worklogId"`
+	UpdatedTime int64            `json:"updatedTime"`
+	Properties  []EntityProperty `json:"properties,omitempty"`
+}
+
+// worklogChangePage is a page of /worklog/updated and /worklog/deleted.
+type worklogChangePage struct {
+	Values   []worklogChange `json:"values"`
+	Since    int64           `json:"since"`
+	Until    int64           `json:"until"`
+	LastPage bool            `json:"lastPage"`
+}
+
+// SyncIterator returns an iterator over the worklogs that were updated or deleted since
+// checkpoint, on all issues the user can see. It yields one page of changes at a time,
+// first the updated worklogs and then the deleted ones, each with the checkpoint to
+// resume from. Apply the changes and save the checkpoint of every page, so that a failed
+// run continues where it stopped:
+//
+//	it := worklogService.SyncIterator(checkpoint)
+//	for it.Next() {
+//		changes := it.Changes()
+//		// apply changes.Updated and changes.Deleted, then save changes.Checkpoint
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// The zero WorklogCheckpoint starts from the beginning. Jira leaves out changes of the
+// last minute, the next sync picks them up.
+//
+// Jira API docs: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-worklogs/#api-rest-api-3-worklog-updated-get
+func (w *WorklogImpl) SyncIterator(checkpoint WorklogCheckpoint) *WorklogSyncIterator {
+	return w.SyncIteratorContext(context.Background(), checkpoint)
+}
+
+// SyncIteratorContext is like SyncIterator but uses ctx for every page request.
+func (w *WorklogImpl) SyncIteratorContext(ctx context.Context, checkpoint WorklogCheckpoint) *WorklogSyncIterator {
+	return &WorklogSyncIterator{
+		ctx:        ctx,
+		service:    w,
+		checkpoint: checkpoint,
+	}
+}
+
+// ListByIDs returns the worklogs with ids, fetching them in batches of 1000.
+// Worklogs that do not exist or that the user cannot see are left out.
+//
+// Jira API docs: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-worklogs/#api-rest-api-3-worklog-list-post
+func (w *WorklogImpl) ListByIDs(ids []int64) ([]WorklogRecord, error) {
+	return w.ListByIDsContext(context.Background(), ids)
+}
+
+// ListByIDsContext is like ListByIDs but stops when ctx is done.
+func (w *WorklogImpl) ListByIDsContext(ctx context.Context, ids []int64) ([]WorklogRecord, error) {
+	if err := w.client.checkScopes("WorklogService.ListByIDs"); err != nil {
+		return nil, err
+	}
+	records := []WorklogRecord{}
+	for start := 0; start < len(ids); start += maxWorklogIDs {
+		end := start + maxWorklogIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+		payload := struct {
+			IDs []int64 `json:"ids"`
+		}{IDs: ids[start:end]}
+
+		var batch []WorklogRecord
+		if err := w.send(ctx, http.MethodPost, "worklog/list", url.Values{}, &payload, &batch); err != nil {
+			return nil, err
+		}
+		records = append(records, batch...)
+	}
+	return records, nil
+}
+
+// WorklogSyncIterator pages through the worklog changes since a checkpoint,
+// see WorklogService.SyncIterator.
+type WorklogSyncIterator struct {
+	ctx        context.Context
+	service    *WorklogImpl
+	checkpoint WorklogCheckpoint
+
+	// deletedPhase is set once the updated worklogs were read
+	deletedPhase bool
+	done         bool
+	current      *WorklogChanges
+	err          error
+}
+
+// Next fetches the next page of changes. It returns false when all changes were read
+// or an error occurred.
+func (it *WorklogSyncIterator) Next() bool {
+	if it.err != nil || it.done {
+		return false
+	}
+	if err := it.fetch(); err != nil {
+		it.err = err
+		return false
+	}
+	return true
+}
+
+// Changes returns the page of changes the iterator currently points at.
+func (it *WorklogSyncIterator) Changes() *WorklogChanges {
+	return it.current
+}
+
+// Checkpoint returns where the sync continues, after the last page that was read.
+func (it *WorklogSyncIterator) Checkpoint() WorklogCheckpoint {
+	return it.checkpoint
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *WorklogSyncIterator) Err() error {
+	return it.err
+}
+
+func (it *WorklogSyncIterator) fetch() error {
+	if err := it.service.client.checkScopes("WorklogService.SyncIterator"); err != nil {
+		return err
+	}
+	changes := &WorklogChanges{Updated: []WorklogRecord{}, Deleted: []int64{}}
+
+	if !it.deletedPhase {
+		page, until, err := it.service.changePage(it.ctx, "worklog/updated", it.checkpoint.UpdatedSince)
+		if err != nil {
+			return err
+		}
+		ids := make([]int64, len(page.Values))
+		for i, change := range page.Values {
+			ids[i] = change.WorklogID
+		}
+		records, err := it.service.ListByIDsContext(it.ctx, ids)
+		if err != nil {
+			return err
+		}
+		changes.Updated = records
+		it.checkpoint.UpdatedSince = until
+		it.deletedPhase = page.LastPage
+	} else {
+		page, until, err := it.service.changePage(it.ctx, "worklog/deleted", it.checkpoint.DeletedSince)
+		if err != nil {
+			return err
+		}
+		for _, change := range page.Values {
+			changes.Deleted = append(changes.Deleted, change.WorklogID)
+		}
+		it.checkpoint.DeletedSince = until
+		it.done = page.LastPage
+	}
+
+	changes.Checkpoint = it.checkpoint
+	it.current = changes
+	return nil
+}
+
+// changePage fetches the page of resource that starts at since and returns it together
+// with where the next page starts. A page that does not move on counts as the last one.
+func (w *WorklogImpl) changePage(ctx context.Context, resource string, since time.Time) (*worklogChangePage, time.Time, error) {
+	sinceMilli := int64(0)
+	if !since.IsZero() {
+		sinceMilli = unixMilli(since)
+	}
+	uv := url.Values{}
+	uv.Add("since", strconv.FormatInt(sinceMilli, 10))
+
+	var page worklogChangePage
+	if err := w.send(ctx, http.MethodGet, resource, uv, nil, &page); err != nil {
+		return nil, time.Time{}, err
+	}
+	if page.Until <= sinceMilli {
+		page.LastPage = true
+		return &page, since, nil
+	}
+	return &page, fromUnixMilli(page.Until), nil
+}
+
+// fromUnixMilli returns the time of a timestamp in milliseconds since the epoch.
+func fromUnixMilli(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
@@ -0,0 +1,135 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWorklogService_Sync(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	testMux.HandleFunc("/rest/api/3/worklog/updated", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("since") {
+		case "1000":
+			_, _ = w.Write([]byte(`{"values":[{"worklogId":1,"updatedTime":1500},{"worklogId":2,"updatedTime":1600}],"since":1000,"until":1600,"lastPage":false}`))
+		case "1600":
+			_, _ = w.Write([]byte(`{"values":[{"worklogId":3,"updatedTime":1700}],"since":1600,"until":1700,"lastPage":true}`))
+		case "1700":
+			_, _ = w.Write([]byte(`{"values":[],"since":1700,"until":1700,"lastPage":true}`))
+		default:
+			t.Fatal("Did not want since", r.URL.Query().Get("since"))
+		}
+	})
+	failDeleted := true
+	testMux.HandleFunc("/rest/api/3/worklog/deleted", func(w http.ResponseWriter, r *http.Request) {
+		if failDeleted {
+			w.WriteHeader(500)
+			return
+		}
+		if r.URL.Query().Get("since") != "2000" {
+			t.Error("Wanted since 2000 but got", r.URL.Query().Get("since"))
+		}
+		_, _ = w.Write([]byte(`{"values":[{"worklogId":9,"updatedTime":2500}],"since":2000,"until":2500,"lastPage":true}`))
+	})
+	testMux.HandleFunc("/rest/api/3/worklog/list", func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			IDs []int64 `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		records := make([]WorklogRecord, len(payload.IDs))
+		for i, id := range payload.IDs {
+			records[i].ID = fmt.Sprint(id)
+		}
+		if err := json.NewEncoder(w).Encode(records); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	checkpoint := WorklogCheckpoint{UpdatedSince: fromUnixMilli(1000), DeletedSince: fromUnixMilli(2000)}
+	it := testClient.GetWorklogService().SyncIterator(checkpoint)
+	updated := 0
+	for it.Next() {
+		changes := it.Changes()
+		updated += len(changes.Updated)
+		// saved after every page
+		checkpoint = changes.Checkpoint
+	}
+	if it.Err() == nil {
+		t.Fatal("Wanted the failing deleted feed to stop the sync")
+	}
+	if updated != 3 {
+		t.Fatal("Wanted the 3 updated worklogs before the failure but got", updated)
+	}
+	if !checkpoint.UpdatedSince.Equal(fromUnixMilli(1700)) || !checkpoint.DeletedSince.Equal(fromUnixMilli(2000)) {
+		t.Fatal("Wanted the checkpoint after the updated feed but got", checkpoint)
+	}
+
+	// the next run resumes from the saved checkpoint and does not fetch the updated worklogs again
+	failDeleted = false
+	updated = 0
+	it = testClient.GetWorklogService().SyncIterator(checkpoint)
+	var deleted []int64
+	for it.Next() {
+		changes := it.Changes()
+		updated += len(changes.Updated)
+		deleted = append(deleted, changes.Deleted...)
+		checkpoint = changes.Checkpoint
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if updated != 0 {
+		t.Fatal("Wanted no updated worklogs after resuming but got", updated)
+	}
+	if len(deleted) != 1 || deleted[0] != 9 {
+		t.Fatal("Wanted worklog 9 to be deleted but got", deleted)
+	}
+	if !checkpoint.UpdatedSince.Equal(fromUnixMilli(1700)) || !checkpoint.DeletedSince.Equal(fromUnixMilli(2500)) {
+		t.Fatal("Wanted the checkpoint at the last until but got", checkpoint)
+	}
+}
+
+func TestWorklogService_ListByIDsBatches(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	batches := 0
+	testMux.HandleFunc("/rest/api/3/worklog/list", func(w http.ResponseWriter, r *http.Request) {
+		batches++
+		var payload struct {
+			IDs []int64 `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if len(payload.IDs) > 1000 {
+			t.Fatal("Wanted at most 1000 ids but got", len(payload.IDs))
+		}
+		records := make([]WorklogRecord, len(payload.IDs))
+		for i, id := range payload.IDs {
+			records[i].ID = fmt.Sprint(id)
+		}
+		if err := json.NewEncoder(w).Encode(records); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ids := make([]int64, 2500)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	records, err := testClient.GetWorklogService().ListByIDs(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batches != 3 || len(records) != 2500 || records[2499].ID != "2500" {
+		t.Fatal("Wanted 2500 worklogs in 3 batches but got", len(records), "in", batches)
+	}
+}
@@ -0,0 +1,298 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type WorklogImpl struct {
+	client *client
+}
+
+type WorklogService interface {
+	List(issueKey string, options *WorklogListOptions) (*Worklog, error)
+	ListContext(ctx context.Context, issueKey string, options *WorklogListOptions) (*Worklog, error)
+	Get(issueKey string, id string, options *WorklogOptions) (*WorklogRecord, error)
+	GetContext(ctx context.Context, issueKey string, id string, options *WorklogOptions) (*WorklogRecord, error)
+	Add(issueKey string, worklog *WorkLog, options *WorklogOptions) (*WorklogRecord, error)
+	AddContext(ctx context.Context, issueKey string, worklog *WorkLog, options *WorklogOptions) (*WorklogRecord, error)
+	Update(issueKey string, id string, worklog *WorkLog, options *WorklogOptions) (*WorklogRecord, error)
+	UpdateContext(ctx context.Context, issueKey string, id string, worklog *WorkLog, options *WorklogOptions) (*WorklogRecord, error)
+	Delete(issueKey string, id string, options *WorklogOptions) error
+	DeleteContext(ctx context.Context, issueKey string, id string, options *WorklogOptions) error
+	ListByIDs(ids []int64) ([]WorklogRecord, error)
+	ListByIDsContext(ctx context.Context, ids []int64) ([]WorklogRecord, error)
+	SyncIterator(checkpoint WorklogCheckpoint) *WorklogSyncIterator
+	SyncIteratorContext(ctx context.Context, checkpoint WorklogCheckpoint) *WorklogSyncIterator
+}
+
+// The ways Jira can change the remaining estimate of an issue when work is logged.
+const (
+	// AdjustEstimateAuto reduces the remaining estimate by the time spent, the default
+	AdjustEstimateAuto = "auto"
+	// AdjustEstimateLeave leaves the remaining estimate as it is
+	AdjustEstimateLeave = "leave"
+	// AdjustEstimateNew sets the remaining estimate to WorklogOptions.NewEstimate
+	AdjustEstimateNew = "new"
+	// AdjustEstimateManual reduces the remaining estimate by WorklogOptions.ReduceBy,
+	// or increases it by WorklogOptions.IncreaseBy when a worklog is deleted
+	AdjustEstimateManual = "manual"
+)
+
+// WorklogListOptions specifies the optional parameters of WorklogService.List.
+type WorklogListOptions struct {
+	StartAt    int
+	MaxResults int
+	// StartedAfter and StartedBefore only return worklogs started in that range
+	StartedAfter  time.Time
+	StartedBefore time.Time
+	Expand        string
+}
+
+// WorklogOptions specifies the optional parameters of the WorklogService methods that
+// change worklogs. Get only uses Expand.
+type WorklogOptions struct {
+	// AdjustEstimate is one of the AdjustEstimate constants
+	AdjustEstimate string
+	// NewEstimate is the remaining estimate for AdjustEstimateNew, e.g. "2d"
+	NewEstimate string
+	// ReduceBy is subtracted from the remaining estimate for AdjustEstimateManual
+	ReduceBy string
+	// IncreaseBy is added to the remaining estimate for AdjustEstimateManual on Delete
+	IncreaseBy string
+	// NotifyUsers set to false does not notify the watchers of the issue, Jira only
+	// allows it to administrators
+	NotifyUsers *bool
+	Expand      string
+}
+
+func (o *WorklogOptions) values() url.Values {
+	uv := url.Values{}
+	if o == nil {
+		return uv
+	}
+	if o.AdjustEstimate != "" {
+		uv.Add("adjustEstimate", o.AdjustEstimate)
+	}
+	if o.NewEstimate != "" {
+		uv.Add("newEstimate", o.NewEstimate)
+	}
+	if o.ReduceBy != "" {
+		uv.Add("reduceBy", o.ReduceBy)
+	}
+	if o.IncreaseBy != "" {
+		uv.Add("increaseBy", o.IncreaseBy)
+	}
+	if o.NotifyUsers != nil {
+		uv.Add("notifyUsers", strconv.FormatBool(*o.NotifyUsers))
+	}
+	if o.Expand != "" {
+		uv.Add("expand", o.Expand)
+	}
+	return uv
+}
+
+// Jira API docs: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-worklogs/#api-rest-api-3-issue-issueidorkey-worklog-get
+func (w *WorklogImpl) List(issueKey string, options *WorklogListOptions) (*Worklog, error) {
+	return w.ListContext(context.Background(), issueKey, options)
+}
+
+// ListContext is like List but cancels the request when ctx is done.
+func (w *WorklogImpl) ListContext(ctx context.Context, issueKey string, options *WorklogListOptions) (*Worklog, error) {
+	if err := w.client.checkScopes("WorklogService.List"); err != nil {
+		return nil, err
+	}
+	uv := url.Values{}
+	if options != nil {
+		if options.StartAt != 0 {
+			uv.Add("startAt", strconv.Itoa(options.StartAt))
+		}
+		if options.MaxResults != 0 {
+			uv.Add("maxResults", strconv.Itoa(options.MaxResults))
+		}
+		if !options.StartedAfter.IsZero() {
+			uv.Add("startedAfter", strconv.FormatInt(unixMilli(options.StartedAfter), 10))
+		}
+		if !options.StartedBefore.IsZero() {
+			uv.Add("startedBefore", strconv.FormatInt(unixMilli(options.StartedBefore), 10))
+		}
+		if options.Expand != "" {
+			uv.Add("expand", options.Expand)
+		}
+	}
+
+	v := Worklog{Worklogs: []WorklogRecord{}}
+	resource, err := w.listPath(issueKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.send(ctx, http.MethodGet, resource, uv, nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (w *WorklogImpl) Get(issueKey string, id string, options *WorklogOptions) (*WorklogRecord, error) {
+	return w.GetContext(context.Background(), issueKey, id, options)
+}
+
+// GetContext is like Get but cancels the request when ctx is done.
+func (w *WorklogImpl) GetContext(ctx context.Context, issueKey string, id string, options *WorklogOptions) (*WorklogRecord, error) {
+	if err := w.client.checkScopes("WorklogService.Get"); err != nil {
+		return nil, err
+	}
+	uv := url.Values{}
+	if options != nil && options.Expand != "" {
+		uv.Add("expand", options.Expand)
+	}
+	var v WorklogRecord
+	resource, err := w.path(issueKey, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.send(ctx, http.MethodGet, resource, uv, nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Add logs work on the issue and returns the new worklog. Set worklog.Started to the
+// time the work began, Jira takes the current time otherwise.
+func (w *WorklogImpl) Add(issueKey string, worklog *WorkLog, options *WorklogOptions) (*WorklogRecord, error) {
+	return w.AddContext(context.Background(), issueKey, worklog, options)
+}
+
+// AddContext is like Add but cancels the request when ctx is done.
+func (w *WorklogImpl) AddContext(ctx context.Context, issueKey string, worklog *WorkLog, options *WorklogOptions) (*WorklogRecord, error) {
+	if err := w.client.checkScopes("WorklogService.Add"); err != nil {
+		return nil, err
+	}
+	var v WorklogRecord
+	resource, err := w.listPath(issueKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.send(ctx, http.MethodPost, resource, options.values(), w.body(worklog), &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Update changes the worklog with id, fields left empty in worklog stay as they are.
+func (w *WorklogImpl) Update(issueKey string, id string, worklog *WorkLog, options *WorklogOptions) (*WorklogRecord, error) {
+	return w.UpdateContext(context.Background(), issueKey, id, worklog, options)
+}
+
+// UpdateContext is like Update but cancels the request when ctx is done.
+func (w *WorklogImpl) UpdateContext(ctx context.Context, issueKey string, id string, worklog *WorkLog, options *WorklogOptions) (*WorklogRecord, error) {
+	if err := w.client.checkScopes("WorklogService.Update"); err != nil {
+		return nil, err
+	}
+	var v WorklogRecord
+	resource, err := w.path(issueKey, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.send(ctx, http.MethodPut, resource, options.values(), w.body(worklog), &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (w *WorklogImpl) Delete(issueKey string, id string, options *WorklogOptions) error {
+	return w.DeleteContext(context.Background(), issueKey, id, options)
+}
+
+// DeleteContext is like Delete but cancels the request when ctx is done.
+func (w *WorklogImpl) DeleteContext(ctx context.Context, issueKey string, id string, options *WorklogOptions) error {
+	if err := w.client.checkScopes("WorklogService.Delete"); err != nil {
+		return err
+	}
+	resource, err := w.path(issueKey, id)
+	if err != nil {
+		return err
+	}
+	return w.send(ctx, http.MethodDelete, resource, options.values(), nil, nil)
+}
+
+// listPath returns the worklogs of the issue.
+func (w *WorklogImpl) listPath(issueKey string) (string, error) {
+	if issueKey == "" {
+		return "", fmt.Errorf("%w: issue key", ErrEmptyID)
+	}
+	return fmt.Sprintf("issue/%v/worklog", pathSegment(issueKey)), nil
+}
+
+// path returns the worklog with id of the issue.
+func (w *WorklogImpl) path(issueKey string, id string) (string, error) {
+	resource, err := w.listPath(issueKey)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", fmt.Errorf("%w: worklog ID", ErrEmptyID)
+	}
+	return resource + "/" + pathSegment(id), nil
+}
+
+// send sends a request to the REST resource with payload as JSON body, if any, and
+// decodes the response into v, unless v is nil.
+func (w *WorklogImpl) send(ctx context.Context, method string, resource string, uv url.Values, payload interface{}, v interface{}) error {
+	u := w.client.restURL(resource)
+	u.RawQuery = uv.Encode()
+
+	var body io.Reader
+	if payload != nil {
+		requestBody, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(requestBody)
+	}
+
+	req, err := w.client.newRequestContext(ctx, method, u.String(), body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.sendRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// body adds the comment to worklog in the format of the API version of the client.
+func (w *WorklogImpl) body(worklog *WorkLog) interface{} {
+	if worklog == nil {
+		return nil
+	}
+	var comment interface{}
+	if worklog.Comment != "" {
+		comment = worklog.Comment
+		if w.client.apiVersion != "2" {
+			comment = adfDocument(worklog.Comment)
+		}
+	}
+	return &struct {
+		*WorkLog
+		Comment interface{} `json:"comment,omitempty"`
+	}{WorkLog: worklog, Comment: comment}
+}
+
+// unixMilli returns t in milliseconds since the epoch, as Jira expects timestamps in queries.
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,182 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorklogService_Add(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	testMux.HandleFunc("/rest/api/3/issue/TEST-1/worklog", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Fatal("Wanted POST but got", r.Method)
+		}
+		q := r.URL.Query()
+		if q.Get("adjustEstimate") != AdjustEstimateManual || q.Get("reduceBy") != "1h" || q.Get("notifyUsers") != "false" {
+			t.Error("Wanted the estimate and notification parameters but got", r.URL.RawQuery)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body["started"] != "2021-01-17T12:34:00.000+0000" {
+			t.Error("Wanted the start time but got", body["started"])
+		}
+		comment, ok := body["comment"].(map[string]interface{})
+		if !ok || comment["type"] != "doc" {
+			t.Error("Wanted the comment as ADF document but got", body["comment"])
+		}
+		w.WriteHeader(201)
+		_, _ = w.Write([]byte(`{"id":"100","timeSpent":"1h","started":"2021-01-17T12:34:00.000+0000","visibility":{"type":"role","value":"Developers"},
+			"comment":{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"first"}]},{"type":"paragraph","content":[{"type":"text","text":"second"}]}]}}`))
+	})
+
+	started := Time(time.Date(2021, 1, 17, 12, 34, 0, 0, time.UTC))
+	notify := false
+	record, err := testClient.GetWorklogService().Add("TEST-1", &WorkLog{
+		TimeSpent:  "1h",
+		Started:    &started,
+		Comment:    "first\nsecond",
+		Visibility: &CommentVisibility{Type: "role", Value: "Developers"},
+	}, &WorklogOptions{AdjustEstimate: AdjustEstimateManual, ReduceBy: "1h", NotifyUsers: &notify})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.ID != "100" || record.Comment != "first\nsecond" {
+		t.Fatal("Wanted worklog 100 with the comment text but got", record.ID, record.Comment)
+	}
+	if record.Visibility == nil || record.Visibility.Value != "Developers" {
+		t.Fatal("Wanted the visibility but got", record.Visibility)
+	}
+	if !record.Started.Equal(started) {
+		t.Fatal("Wanted", time.Time(started), "but got", time.Time(*record.Started))
+	}
+}
+
+func TestWorklogService_ListUpdateDelete(t *testing.T) {
+	setup()
+	defer teardown()
+
+	addr := strings.ReplaceAll(testServer.URL, "http://", "")
+	c := New(addr, WithScheme("http"), WithDataCenter("pat"))
+
+	testMux.HandleFunc("/rest/api/2/issue/TEST-1/worklog", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("startedAfter") != "1610886840000" {
+			t.Error("Wanted startedAfter in milliseconds but got", r.URL.Query().Get("startedAfter"))
+		}
+		_, _ = w.Write([]byte(`{"startAt":0,"maxResults":1,"total":1,"worklogs":[{"id":"100","comment":"plain"}]}`))
+	})
+	testMux.HandleFunc("/rest/api/2/issue/TEST-1/worklog/100", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body["comment"] != "changed" {
+				t.Error("Wanted the comment as string on v2 but got", body["comment"])
+			}
+			if _, ok := body["timeSpent"]; ok {
+				t.Error("Did not want an empty timeSpent but got", body["timeSpent"])
+			}
+			_, _ = w.Write([]byte(`{"id":"100","comment":"changed"}`))
+		case "DELETE":
+			if r.URL.Query().Get("increaseBy") != "2h" {
+				t.Error("Wanted increaseBy but got", r.URL.RawQuery)
+			}
+			w.WriteHeader(204)
+		default:
+			t.Fatal("Did not want", r.Method)
+		}
+	})
+
+	worklogs := c.GetWorklogService()
+	list, err := worklogs.List("TEST-1", &WorklogListOptions{StartedAfter: time.Date(2021, 1, 17, 12, 34, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Worklogs) != 1 || list.Worklogs[0].Comment != "plain" {
+		t.Fatal("Wanted the worklog with a plain comment but got", list.Worklogs)
+	}
+
+	record, err := worklogs.Update("TEST-1", "100", &WorkLog{Comment: "changed"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Comment != "changed" {
+		t.Fatal("Wanted changed but got", record.Comment)
+	}
+
+	err = worklogs.Delete("TEST-1", "100", &WorklogOptions{AdjustEstimate: AdjustEstimateManual, IncreaseBy: "2h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIssueService_UpdateAddsWorklog(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	testMux.HandleFunc("/rest/api/3/issue/TEST-1/worklog", func(w http.ResponseWriter, r *http.Request) {
+		var body WorkLog
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.TimeSpent != "3h 20m" {
+			t.Error("Wanted 3h 20m but got", body.TimeSpent)
+		}
+		w.WriteHeader(201)
+		_, _ = w.Write([]byte(`{"id":"100","timeSpent":"3h 20m"}`))
+	})
+
+	if err := testClient.GetIssueService().Update("TEST-1", "3h 20m"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorklogService_EscapesPath(t *testing.T) {
+	setup()
+	defer teardown()
+
+	w := testClient.GetWorklogService().(*WorklogImpl)
+	resource, err := w.path("../../myself?", "..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := testClient.restURL(resource)
+	if u.EscapedPath() != "/rest/api/3/issue/..%2F..%2Fmyself%3F/worklog/%2E%2E" {
+		t.Fatal("Wanted key and id as single escaped segments but got", u.EscapedPath())
+	}
+}
+
+func TestWorklogService_EmptyID(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient.GetAuthService().SetAccessToken("token")
+	testMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Wanted no request but got", r.Method, r.URL.Path)
+	})
+
+	worklogs := testClient.GetWorklogService()
+	if err := worklogs.Delete("TEST-1", "", nil); !errors.Is(err, ErrEmptyID) {
+		t.Fatal("Wanted ErrEmptyID for an empty worklog ID but got", err)
+	}
+	if _, err := worklogs.Update("TEST-1", "", &WorkLog{TimeSpent: "1h"}, nil); !errors.Is(err, ErrEmptyID) {
+		t.Fatal("Wanted ErrEmptyID for an empty worklog ID but got", err)
+	}
+	if _, err := worklogs.List("", nil); !errors.Is(err, ErrEmptyID) {
+		t.Fatal("Wanted ErrEmptyID for an empty issue key but got", err)
+	}
+	if _, err := worklogs.Get("", "10000", nil); !errors.Is(err, ErrEmptyID) {
+		t.Fatal("Wanted ErrEmptyID for an empty issue key but got", err)
+	}
+}